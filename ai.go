@@ -0,0 +1,335 @@
+// ai.go
+package main
+
+import (
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 内置 AI: 迭代加深的 alpha-beta 搜索, 根节点先检查连续冲四 (VCF).
+// 多线程时采用 lazy SMP: 各线程独立搜索同一局面, 通过共享的置换表互相利用结果
+
+const (
+	scoreWin       = 1_000_000 // 成五; 减去步数, 越快赢分越高
+	scoreWinMargin = 1000      // 分值在 scoreWin-scoreWinMargin 以上视为已经算出胜负
+	aiDefaultDepth = 4         // 默认搜索深度 (半回合)
+	aiDefaultWidth = 12        // 每层默认考虑的候选点数
+	aiRootVCFDepth = 8         // 根节点 VCF 检查的默认深度
+	aiRootVCFNodes = 2000      // 根节点 VCF 检查的节点上限
+	aiNeighborhood = 2         // 候选点: 与已有棋子相距不超过 2 格的空位
+)
+
+// 五格窗口中只有一方 n 个棋子时的分值
+var windowScores = [6]int{0, 1, 8, 64, 512, scoreWin}
+
+// AI 的参数; 零值不可用, 用 NewAI 创建
+type AI struct {
+	Depth     int           // 最大搜索深度 (半回合)
+	Width     int           // 每层最多考虑的候选点
+	TimeLimit time.Duration // 每手的时间上限, 0 表示只受 Depth 限制
+	Book      *OpeningBook  // 开局库, 只在引擎落子时查询 (builtinEngine.NextMove), 分析和复盘不用; 可以为 nil
+	Threads   int           // 搜索线程数, 小于 1 时按 1
+	VCFDepth  int           // 根节点 VCF 检查的深度, 0 表示不检查
+	Blunder   float64       // 故意随手的概率 (降低难度): 在候选点中随机选一手, 但不会漏掉成五和必须挡的点
+
+	tt *transTable // 置换表, 第一次搜索时创建, 之后各手之间保留
+}
+
+func NewAI() *AI {
+	return &AI{Depth: aiDefaultDepth, Width: aiDefaultWidth, Threads: 1, VCFDepth: aiRootVCFDepth}
+}
+
+// 一次搜索的结果
+type SearchResult struct {
+	Move  Move
+	Score int    // 对行棋方的评估; 接近 ±scoreWin 表示算出了胜负
+	Depth int    // 完成的搜索深度
+	Nodes int    // 搜索的节点数
+	PV    []Move // 主要变化, 从 Move 开始
+}
+
+// 为 player 选择下一手, 不修改 board; 棋盘已满时返回的 Move.Player 为 0
+func (ai *AI) Search(board [][]int, player int) SearchResult {
+	s := &aiSearch{ai: ai, board: NewBoard(len(board))}
+	stones := 0
+	for i := range board {
+		copy(s.board[i], board[i])
+		for _, p := range board[i] {
+			if p != Empty {
+				stones++
+			}
+		}
+	}
+	if stones == 0 { // 第一手下在天元
+		c := len(board) / 2
+		m := Move{Player: player, X: c, Y: c}
+		return SearchResult{Move: m, PV: []Move{m}}
+	}
+	if ai.TimeLimit > 0 {
+		s.deadline = time.Now().Add(ai.TimeLimit)
+	}
+
+	if ai.Blunder > 0 && rand.Float64() < ai.Blunder {
+		if cands, win, blocks := s.candidates(player); win == nil && len(blocks) == 0 && len(cands) > 0 {
+			m := cands[rand.IntN(min(len(cands), ai.Width))]
+			return SearchResult{Move: m, PV: []Move{m}}
+		}
+	}
+
+	// 有连续冲四就直接走, 比 alpha-beta 看得远
+	if ai.VCFDepth > 0 && len(fivePoints(board, 3-player)) == 0 {
+		vcf := Solve(board, player, SolveOptions{MaxDepth: ai.VCFDepth, MaxNodes: aiRootVCFNodes})
+		if vcf.Line != nil {
+			return SearchResult{Move: vcf.Line[0], Score: scoreWin - len(vcf.Line), Nodes: vcf.Nodes, PV: vcf.Line}
+		}
+	}
+
+	if ai.tt == nil {
+		ai.tt = newTransTable(ttDefaultBits)
+	}
+	s.tt, s.stop, s.bits = ai.tt, new(atomic.Bool), BitboardFrom(board)
+
+	// 辅助线程从不同的深度开始, 错开搜索顺序; 主线程搜完后通知它们停止
+	var wg sync.WaitGroup
+	helpers := make([]*aiSearch, max(ai.Threads, 1)-1)
+	for i := range helpers {
+		h := &aiSearch{ai: ai, board: NewBoard(len(board)), deadline: s.deadline, tt: s.tt, stop: s.stop, bits: s.bits}
+		for r := range board {
+			copy(h.board[r], board[r])
+		}
+		helpers[i] = h
+		wg.Add(1)
+		go func(start int) {
+			defer wg.Done()
+			h.deepen(player, start)
+		}(2 + i%2)
+	}
+	res := s.deepen(player, 1)
+	s.stop.Store(true)
+	wg.Wait()
+	for _, h := range helpers {
+		res.Nodes += h.nodes
+	}
+	return res
+}
+
+type aiSearch struct {
+	ai       *AI
+	board    [][]int
+	bits     Bitboard // 与 board 同步, 提供置换表用的哈希
+	nodes    int
+	deadline time.Time
+	tt       *transTable  // 所有线程共享
+	stop     *atomic.Bool // 所有线程共享的停止标志
+	stopped  bool
+}
+
+// 从 start 层开始迭代加深, 直到 ai.Depth, 超时或收到停止通知
+func (s *aiSearch) deepen(player, start int) SearchResult {
+	var res SearchResult
+	for depth := start; depth <= s.ai.Depth; depth++ {
+		score, pv := s.negamax(depth, -scoreWin-1, scoreWin+1, player, 0)
+		if s.stopped && res.PV != nil {
+			break // 这一层没有搜完, 用上一层的结果
+		}
+		res.Score, res.PV, res.Depth = score, pv, depth
+		if len(pv) > 0 {
+			res.Move = pv[0]
+		}
+		if s.stopped || score >= scoreWin-scoreWinMargin || score <= -scoreWin+scoreWinMargin {
+			break
+		}
+	}
+	res.Nodes = s.nodes
+	return res
+}
+
+func (s *aiSearch) place(x, y, player int) {
+	s.board[x][y] = player
+	s.bits.Place(x, y, player)
+}
+
+func (s *aiSearch) undo(x, y int) {
+	s.board[x][y] = Empty
+	s.bits.Remove(x, y)
+}
+
+// 对 player 而言的分值, 返回主要变化
+func (s *aiSearch) negamax(depth, alpha, beta, player, ply int) (int, []Move) {
+	s.nodes++
+	if s.nodes%64 == 0 && (s.stop.Load() || !s.deadline.IsZero() && time.Now().After(s.deadline)) {
+		s.stopped = true
+	}
+	opp := 3 - player
+	key := s.bits.Hash() ^ zobristSide[player]
+	ttMove := -1
+	if e, ok := s.tt.probe(key); ok && depth > 0 {
+		ttMove = e.move
+		// 根节点需要完整的主要变化, 不直接用表中的分值
+		if score := scoreFromTT(e.score, ply); ply > 0 && e.depth >= depth &&
+			(e.bound == boundExact || e.bound == boundLower && score >= beta || e.bound == boundUpper && score <= alpha) {
+			var pv []Move
+			if e.move >= 0 {
+				pv = []Move{{Player: player, X: e.move / BoardSize, Y: e.move % BoardSize}}
+			}
+			return score, pv
+		}
+	}
+
+	cands, win, blocks := s.candidates(player)
+	if win != nil {
+		return scoreWin - ply, []Move{*win}
+	}
+	switch {
+	case len(cands) == 0:
+		return 0, nil // 棋盘已满
+	case len(blocks) >= 2: // 挡不住
+		return -scoreWin + ply + 1, []Move{blocks[0]}
+	case len(blocks) == 1: // 必须挡
+		cands = blocks
+	case depth <= 0 || s.stopped:
+		return evaluate(s.board, player), nil
+	}
+
+	// 表中的最佳着法先搜
+	for i, c := range cands {
+		if c.X*BoardSize+c.Y == ttMove {
+			copy(cands[1:i+1], cands[:i])
+			cands[0] = c
+			break
+		}
+	}
+	if len(cands) > s.ai.Width {
+		cands = cands[:s.ai.Width]
+	}
+	var pv []Move
+	best, origAlpha := -scoreWin-1, alpha
+	for _, c := range cands {
+		s.place(c.X, c.Y, player)
+		score, sub := s.negamax(depth-1, -beta, -alpha, opp, ply+1)
+		score = -score
+		s.undo(c.X, c.Y)
+		if score > best {
+			best = score
+			pv = append([]Move{c}, sub...)
+		}
+		if best > alpha {
+			alpha = best
+		}
+		if alpha >= beta || s.stopped {
+			break
+		}
+	}
+	if !s.stopped && depth > 0 { // 必须挡的着法会延伸到深度 0 以下, 这些节点不记录
+		bound := boundExact
+		switch {
+		case best <= origAlpha:
+			bound = boundUpper
+		case best >= beta:
+			bound = boundLower
+		}
+		s.tt.store(key, ttEntry{score: scoreToTT(best, ply), depth: depth, bound: bound, move: pv[0].X*BoardSize + pv[0].Y})
+	}
+	return best, pv
+}
+
+// 候选点 (按静态分值从高到低), 以及 player 的成五点和需要挡的对方成五点
+func (s *aiSearch) candidates(player int) (cands []Move, win *Move, blocks []Move) {
+	size := len(s.board)
+	seen := make([]bool, size*size)
+	type scored struct {
+		m     Move
+		score int
+	}
+	var list []scored
+	for i := range s.board {
+		for j := range s.board[i] {
+			if s.board[i][j] == Empty {
+				continue
+			}
+			for x := max(0, i-aiNeighborhood); x <= min(size-1, i+aiNeighborhood); x++ {
+				for y := max(0, j-aiNeighborhood); y <= min(size-1, j+aiNeighborhood); y++ {
+					if s.board[x][y] != Empty || seen[x*size+y] {
+						continue
+					}
+					seen[x*size+y] = true
+					m := Move{Player: player, X: x, Y: y}
+					if makesFive(s.board, x, y, player) {
+						return nil, &m, nil
+					}
+					if makesFive(s.board, x, y, 3-player) {
+						blocks = append(blocks, m)
+					}
+					// 进攻和防守的价值都算上
+					list = append(list, scored{m, cellScore(s.board, x, y, player) + cellScore(s.board, x, y, 3-player)})
+				}
+			}
+		}
+	}
+	sort.SliceStable(list, func(a, b int) bool { return list[a].score > list[b].score })
+	cands = make([]Move, len(list))
+	for i, c := range list {
+		cands[i] = c.m
+	}
+	return cands, nil, blocks
+}
+
+// player 在空位 (x, y) 落子后, 经过该点的五格窗口增加的分值
+func cellScore(board [][]int, x, y, player int) int {
+	size := len(board)
+	total := 0
+	for _, d := range lineDirs {
+		for start := -4; start <= 0; start++ {
+			own := 0
+			ok := true
+			for k := start; k < start+5; k++ {
+				cx, cy := x+k*d[0], y+k*d[1]
+				if cx < 0 || cx >= size || cy < 0 || cy >= size {
+					ok = false
+					break
+				}
+				if p := board[cx][cy]; p == player {
+					own++
+				} else if p != Empty {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				total += windowScores[own+1] - windowScores[own]
+			}
+		}
+	}
+	return total
+}
+
+// 静态评估: 所有只含一方棋子的五格窗口的分值, 己方减对方
+func evaluate(board [][]int, player int) int {
+	size := len(board)
+	var sum [3]int
+	for i := 0; i < size; i++ {
+		for j := 0; j < size; j++ {
+			for _, d := range lineDirs {
+				ex, ey := i+4*d[0], j+4*d[1]
+				if ex < 0 || ex >= size || ey < 0 || ey >= size {
+					continue
+				}
+				var count [3]int
+				for k := 0; k < 5; k++ {
+					count[board[i+k*d[0]][j+k*d[1]]]++
+				}
+				switch {
+				case count[Player1] > 0 && count[Player2] > 0:
+				case count[Player1] > 0:
+					sum[Player1] += windowScores[count[Player1]]
+				case count[Player2] > 0:
+					sum[Player2] += windowScores[count[Player2]]
+				}
+			}
+		}
+	}
+	return sum[player] - sum[3-player]
+}
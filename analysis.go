@@ -0,0 +1,242 @@
+// analysis.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// 局面分析: analyze 子命令和对局中的 /hint 共用
+const (
+	analysisDepth    = 6               // 攻方最多走几手威胁
+	analysisTimeout  = 3 * time.Second // 搜索的时间上限, 保证及时返回
+	maxListedThreats = 8               // 摘要中每方最多列出的威胁点
+)
+
+// 棋盘上的标记
+const (
+	markWinLine = '*' // 必胜序列中行棋方的落子
+	markBlock   = '!' // 对方的成五点, 必须挡
+)
+
+var threatMarks = map[ThreatKind]rune{
+	ThreatFive:    '5',
+	ThreatWinning: 'W',
+	ThreatFour:    '4',
+	ThreatThree:   '3',
+}
+
+var threatNames = map[ThreatKind]string{
+	ThreatFive:    "threat.five",
+	ThreatWinning: "threat.winning",
+	ThreatFour:    "threat.four",
+	ThreatThree:   "threat.three",
+}
+
+// 一个局面的分析结果
+type Analysis struct {
+	ToMove  int
+	Threats [3][]ThreatPoint // 双方下一手能形成的威胁, 按强度从高到低
+	Win     []Move           // 行棋方的必胜序列 (攻守交替), 没有找到时为 nil
+	WinVCT  bool             // Win 中有活三 (VCT), 否则是连续冲四 (VCF)
+}
+
+// 分析 toMove 行棋时的局面, 不修改 board
+func Analyze(board [][]int, toMove int) *Analysis {
+	a := &Analysis{ToMove: toMove}
+	for _, p := range []int{Player1, Player2} {
+		a.Threats[p] = threatPoints(board, p, ThreatThree)
+	}
+	res := Solve(board, toMove, SolveOptions{VCT: true, MaxDepth: analysisDepth, Timeout: analysisTimeout})
+	a.Win, a.WinVCT = res.Line, res.VCT
+	return a
+}
+
+// 对方下一手就能成五的点
+func (a *Analysis) mustBlock() []ThreatPoint {
+	var points []ThreatPoint
+	for _, t := range a.Threats[3-a.ToMove] {
+		if t.Kind == ThreatFive {
+			points = append(points, t)
+		}
+	}
+	return points
+}
+
+// 棋盘标记: 行棋方的威胁点, 对方的成五点和必胜序列
+func (a *Analysis) Marks() [][]rune {
+	marks := make([][]rune, BoardSize)
+	for i := range marks {
+		marks[i] = make([]rune, BoardSize)
+	}
+	for _, t := range a.Threats[a.ToMove] {
+		marks[t.X][t.Y] = threatMarks[t.Kind]
+	}
+	for _, t := range a.mustBlock() {
+		marks[t.X][t.Y] = markBlock
+	}
+	for _, m := range a.Win {
+		if m.Player == a.ToMove {
+			marks[m.X][m.Y] = markWinLine
+		}
+	}
+	return marks
+}
+
+// 文字说明, 每个元素一行
+func (a *Analysis) Summary(r Renderer, n Notation) []string {
+	me, opp := a.ToMove, 3-a.ToMove
+	lines := []string{T("analysis.to_move", me, r.StoneName(me))}
+	for _, t := range a.mustBlock() {
+		lines = append(lines, T("analysis.must_block", n.Format(t.X, t.Y), r.StoneName(opp)))
+	}
+	switch {
+	case a.Win == nil:
+		lines = append(lines, T("analysis.no_win", r.StoneName(me)))
+	case a.WinVCT:
+		lines = append(lines, T("analysis.vct", r.StoneName(me), formatLine(a.Win, n)))
+	default:
+		lines = append(lines, T("analysis.vcf", r.StoneName(me), formatLine(a.Win, n)))
+	}
+	for _, p := range []int{me, opp} {
+		threats := a.Threats[p]
+		if len(threats) == 0 {
+			lines = append(lines, T("analysis.threats_none", r.StoneName(p)))
+			continue
+		}
+		var items []string
+		for i, t := range threats {
+			if i == maxListedThreats {
+				items = append(items, "...")
+				break
+			}
+			items = append(items, fmt.Sprintf("%s (%s)", n.Format(t.X, t.Y), T(threatNames[t.Kind])))
+		}
+		lines = append(lines, T("analysis.threats", r.StoneName(p), strings.Join(items, ", ")))
+	}
+	return append(lines, T("analysis.legend"))
+}
+
+// 一串落子的坐标, 空格分隔 (不带回合编号, 序列可能从任意一方开始)
+func formatLine(moves []Move, n Notation) string {
+	coords := make([]string, len(moves))
+	for i, m := range moves {
+		coords[i] = n.Format(m.X, m.Y)
+	}
+	return strings.Join(coords, " ")
+}
+
+// --- 对局中的 /hint ---
+
+// 分析当前局面, 在棋盘上标出威胁点, 说明显示在聊天区; 下一手之后标记自动消失.
+// 只用于休闲对局: 对手是引擎 (--vs), 或双方都用 --allow-hints 同意; 联网对局中会告诉对方
+func (gs *GameState) showHint() {
+	gs.mu.Lock()
+	if gs.playerID == 0 || gs.gameOver {
+		gs.mu.Unlock()
+		gs.ShowNotice(T("notice.no_hint"))
+		return
+	}
+	if !gs.hintsOK {
+		gs.mu.Unlock()
+		gs.ShowNotice(T("notice.hint_not_allowed"))
+		return
+	}
+	board := NewBoard(BoardSize)
+	for i := range gs.board {
+		copy(board[i], gs.board[i])
+	}
+	toMove, moveNum := gs.currentPlayer, len(gs.moves)
+	gs.mu.Unlock()
+	gs.SendMessage(Message{Type: MsgTypeNotify, Content: notifyHintUsed}) // 引擎会忽略
+
+	go func() { // 搜索可能需要一点时间, 不阻塞主循环
+		a := Analyze(board, toMove)
+		gs.mu.Lock()
+		gs.hint, gs.hintMoveNum = a, moveNum
+		gs.mu.Unlock()
+		for _, line := range a.Summary(gs.renderer, gs.notation) {
+			gs.AddSystemMessage(line)
+		}
+		gs.SetNeedsRedraw()
+	}()
+}
+
+// --- analyze 子命令 ---
+
+// tictactoe analyze [选项] [记录文件]: 分析记录中的局面 (默认最后一手之后), 打印标记后的棋盘和说明
+func runAnalyze(args []string) {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	movesText := fs.String("moves", "", "Moves to analyze instead of a record file, e.g. \"h8 i9 h9\"")
+	ply := fs.Int("ply", -1, "Analyze the position after this many moves (default: all moves)")
+	lang := fs.String("lang", "", "Interface language, e.g. en or zh-CN (default from $LC_ALL, $LC_MESSAGES or $LANG)")
+	theme := fs.String("theme", "ascii", "Board theme: "+strings.Join(ThemeNames(), ", "))
+	notationName := fs.String("notation", "algebraic", "Coordinates for labels and lines: algebraic or index")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: tictactoe analyze [options] [record-file | -]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	SetLanguage(*lang)
+
+	renderer, err := NewRenderer(*theme)
+	if err != nil {
+		log.Fatal(err)
+	}
+	notation, err := ParseNotation(*notationName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var rec GameRecord
+	switch {
+	case *movesText != "":
+		rec.Moves, _, err = ParseMoveText(*movesText)
+	case fs.NArg() == 1:
+		err = withInputFile(fs.Arg(0), func(r io.Reader) (err error) {
+			rec, err = ReadRecord(r)
+			return err
+		})
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("Failed to load position: %v", err)
+	}
+	g, err := rec.Replay(*ply)
+	if err != nil {
+		log.Fatalf("Failed to load position: %v", err)
+	}
+	moves := g.Moves()
+	view := NewBoardView(g.board, nil)
+	if len(moves) > 0 {
+		view.LastMove = &moves[len(moves)-1]
+	}
+	view.Notation = notation
+
+	if len(moves) > 0 {
+		fmt.Println(Tn("plain.moves", len(moves), FormatMoveList(moves, notation)))
+	}
+	if g.gameOver {
+		for _, line := range renderer.RenderBoard(view) {
+			fmt.Println(line)
+		}
+		fmt.Println(resultText(renderer, g.winner, 0))
+		return
+	}
+	a := Analyze(g.board, g.currentPlayer)
+	view.Marks = a.Marks()
+	for _, line := range renderer.RenderBoard(view) {
+		fmt.Println(line)
+	}
+	fmt.Println()
+	for _, line := range a.Summary(renderer, notation) {
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,443 @@
+// api.go
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	apiMaxWait      = 30 * time.Second // 长轮询的最长等待时间
+	apiSSEKeepalive = 15 * time.Second // SSE 心跳间隔
+	apiMaxBody      = 4 << 10          // 请求体的上限 (字节)
+
+	// 创建对局不需要登录, 所以限制对局的数量和寿命
+	apiMaxOpenGames  = 1000             // 未结束的对局最多这么多, 超出时拒绝创建
+	apiFinishedTTL   = 10 * time.Minute // 结束的对局保留这么久, 供客户端取最终状态
+	apiIdleTTL       = time.Hour        // 未结束的对局这么久没有变化就删除
+	apiSweepInterval = time.Minute
+)
+
+// HTTP API 中的一个玩家席位
+type apiPlayer struct {
+	name  string
+	token string // 加入时分配的令牌, 落子时通过 Authorization: Bearer 提交
+}
+
+// HTTP API 托管的一局棋
+type apiGame struct {
+	mu      sync.Mutex
+	id      string
+	game    *Game
+	players [3]apiPlayer  // 下标 1, 2 对应 Player1, Player2
+	version int           // 每次状态变化加一
+	changed chan struct{} // 状态变化时关闭并替换, 唤醒长轮询和 SSE
+	created time.Time
+	updated time.Time // 最近一次状态变化的时间, 用于清理
+	removed bool      // 已从 GameHub 中删除, SSE 随之结束
+	started time.Time // 第二位玩家加入的时间, 用于统计对局时长
+}
+
+// 对外返回的对局状态
+type GameView struct {
+	ID        string   `json:"id"`
+	Version   int      `json:"version"`
+	Turn      int      `json:"turn"`   // 当前轮到谁 (结束后为 0)
+	Winner    int      `json:"winner"` // 0: 进行中, 1: Player1, 2: Player2, 3: 平局
+	GameOver  bool     `json:"game_over"`
+	Players   []string `json:"players"` // [Player1, Player2] 的用户名, 空串表示等待加入
+	MoveCount int      `json:"move_count"`
+	LastMove  *Move    `json:"last_move,omitempty"`
+	Board     [][]int  `json:"board"`
+}
+
+// 状态快照 (需要在外部加锁调用)
+func (ag *apiGame) viewInternal() GameView {
+	view := GameView{
+		ID:        ag.id,
+		Version:   ag.version,
+		Winner:    ag.game.winner,
+		GameOver:  ag.game.gameOver,
+		Players:   []string{ag.players[Player1].name, ag.players[Player2].name},
+		MoveCount: len(ag.game.moves),
+		Board:     NewBoard(BoardSize),
+	}
+	if !ag.game.gameOver {
+		view.Turn = ag.game.currentPlayer
+	}
+	if n := len(ag.game.moves); n > 0 {
+		last := ag.game.moves[n-1]
+		view.LastMove = &last
+	}
+	for i := range ag.game.board {
+		copy(view.Board[i], ag.game.board[i])
+	}
+	return view
+}
+
+// 通知等待者状态已变化 (需要在外部加锁调用)
+func (ag *apiGame) notifyInternal() {
+	ag.updated = time.Now()
+	ag.version++
+	close(ag.changed)
+	ag.changed = make(chan struct{})
+}
+
+// HTTP API 的对局集合
+type GameHub struct {
+	mu    sync.Mutex
+	games map[string]*apiGame
+	store *UserStore    // 非空时加入对局需要校验凭据 (与 TCP 登录相同)
+	stop  chan struct{} // Close 时关闭, 停止定期清理
+	once  sync.Once
+}
+
+func NewGameHub(store *UserStore) *GameHub {
+	h := &GameHub{games: make(map[string]*apiGame), store: store, stop: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(apiSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				h.sweep(now)
+			case <-h.stop:
+				return
+			}
+		}
+	}()
+	return h
+}
+
+// 停止定期清理; 可以重复调用
+func (h *GameHub) Close() {
+	h.once.Do(func() { close(h.stop) })
+}
+
+// 删除结束超过 apiFinishedTTL 或闲置超过 apiIdleTTL 的对局, 返回剩下的未结束对局数
+func (h *GameHub) sweep(now time.Time) (open int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, ag := range h.games {
+		ag.mu.Lock()
+		idle := now.Sub(ag.updated)
+		expired := idle > apiIdleTTL || (ag.game.gameOver && idle > apiFinishedTTL)
+		if expired {
+			delete(h.games, id)
+			if !ag.started.IsZero() && !ag.game.gameOver { // 开始后没下完就被放弃
+				countGameEnded(ag.started, false)
+			}
+			ag.removed = true
+			ag.notifyInternal() // 唤醒长轮询和 SSE
+			slog.Info("API game removed", "game", id, "idle", idle.Round(time.Second))
+		} else if !ag.game.gameOver {
+			open++
+		}
+		ag.mu.Unlock()
+	}
+	return open
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (h *GameHub) get(id string) *apiGame {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.games[id]
+}
+
+// 路由:
+//
+//	POST /api/games                 创建对局
+//	GET  /api/games                 列出对局
+//	POST /api/games/{id}/join       加入对局, 返回玩家编号和令牌
+//	GET  /api/games/{id}            对局状态; ?wait=<version> 长轮询直到版本变化
+//	GET  /api/games/{id}/moves      落子记录
+//	POST /api/games/{id}/moves      落子 (Authorization: Bearer <token>)
+//	GET  /api/games/{id}/events     SSE 推送状态变化
+func (h *GameHub) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/games", h.handleCreate)
+	mux.HandleFunc("GET /api/games", h.handleList)
+	mux.HandleFunc("POST /api/games/{id}/join", h.withGame(h.handleJoin))
+	mux.HandleFunc("GET /api/games/{id}", h.withGame(h.handleState))
+	mux.HandleFunc("GET /api/games/{id}/moves", h.withGame(h.handleMoves))
+	mux.HandleFunc("POST /api/games/{id}/moves", h.withGame(h.handleMove))
+	mux.HandleFunc("GET /api/games/{id}/events", h.withGame(h.handleEvents))
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// 解析路径中的对局 id, 不存在时返回 404
+func (h *GameHub) withGame(fn func(http.ResponseWriter, *http.Request, *apiGame)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ag := h.get(r.PathValue("id"))
+		if ag == nil {
+			writeError(w, http.StatusNotFound, errors.New("game not found"))
+			return
+		}
+		fn(w, r, ag)
+	}
+}
+
+func (h *GameHub) handleCreate(w http.ResponseWriter, r *http.Request) {
+	if h.sweep(time.Now()) >= apiMaxOpenGames {
+		writeError(w, http.StatusServiceUnavailable, errors.New("too many open games"))
+		return
+	}
+	now := time.Now()
+	ag := &apiGame{
+		id:      randomHex(8),
+		game:    NewGame(),
+		changed: make(chan struct{}),
+		created: now,
+		updated: now,
+	}
+	h.mu.Lock()
+	h.games[ag.id] = ag
+	h.mu.Unlock()
+	slog.Info("API game created", "game", ag.id)
+
+	ag.mu.Lock()
+	view := ag.viewInternal()
+	ag.mu.Unlock()
+	writeJSON(w, http.StatusCreated, view)
+}
+
+func (h *GameHub) handleList(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	games := make([]*apiGame, 0, len(h.games))
+	for _, ag := range h.games {
+		games = append(games, ag)
+	}
+	h.mu.Unlock()
+
+	views := make([]GameView, 0, len(games))
+	for _, ag := range games {
+		ag.mu.Lock()
+		view := ag.viewInternal()
+		ag.mu.Unlock()
+		view.Board = nil // 列表中不返回棋盘
+		views = append(views, view)
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+// 加入请求; 服务器有用户文件时需要密码或令牌
+type joinRequest struct {
+	User     string `json:"user"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+type joinResponse struct {
+	Player int    `json:"player"`
+	Token  string `json:"token"`
+}
+
+func (h *GameHub) handleJoin(w http.ResponseWriter, r *http.Request, ag *apiGame) {
+	var req joinRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, apiMaxBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	req.User = strings.TrimSpace(req.User)
+	if h.store != nil {
+		if err := h.store.Authenticate(req.User, req.Password, req.Token); err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+	}
+
+	ag.mu.Lock()
+	defer ag.mu.Unlock()
+	player := 0
+	for _, p := range []int{Player1, Player2} {
+		if ag.players[p].token == "" {
+			player = p
+			break
+		}
+	}
+	if player == 0 {
+		writeError(w, http.StatusConflict, errors.New("game is full"))
+		return
+	}
+	// 有用户文件时用户名是校验过的身份, 同一个用户不能占两个席位 (自己和自己下)
+	if h.store != nil && ag.players[3-player].token != "" && ag.players[3-player].name == req.User {
+		writeError(w, http.StatusConflict, errors.New("already joined this game"))
+		return
+	}
+	if req.User == "" {
+		req.User = fmt.Sprintf("Player %d", player)
+	}
+	ag.players[player] = apiPlayer{name: req.User, token: randomHex(16)}
+	if ag.players[3-player].token != "" { // 双方到齐, 对局开始
+		ag.started = time.Now()
+		metricActiveGames.Inc()
+	}
+	ag.notifyInternal()
+	slog.Info("Player joined API game", "game", ag.id, "user", req.User, "player", player)
+	writeJSON(w, http.StatusOK, joinResponse{Player: player, Token: ag.players[player].token})
+}
+
+func (h *GameHub) handleState(w http.ResponseWriter, r *http.Request, ag *apiGame) {
+	if waitStr := r.URL.Query().Get("wait"); waitStr != "" {
+		// 长轮询: 客户端版本与当前版本相同时, 等待变化或超时
+		since, err := strconv.Atoi(waitStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("wait must be a version number"))
+			return
+		}
+		ag.mu.Lock()
+		version, changed := ag.version, ag.changed
+		ag.mu.Unlock()
+		if version == since {
+			select {
+			case <-changed:
+			case <-time.After(apiMaxWait):
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+	ag.mu.Lock()
+	view := ag.viewInternal()
+	ag.mu.Unlock()
+	writeJSON(w, http.StatusOK, view)
+}
+
+func (h *GameHub) handleMoves(w http.ResponseWriter, r *http.Request, ag *apiGame) {
+	ag.mu.Lock()
+	moves := ag.game.Moves()
+	ag.mu.Unlock()
+	writeJSON(w, http.StatusOK, moves)
+}
+
+type moveRequest struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func (h *GameHub) handleMove(w http.ResponseWriter, r *http.Request, ag *apiGame) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("missing bearer token"))
+		return
+	}
+	var req moveRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, apiMaxBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	ag.mu.Lock()
+	defer ag.mu.Unlock()
+	// 玩家身份只取决于令牌, 与请求内容无关
+	player := 0
+	for _, p := range []int{Player1, Player2} {
+		if ag.players[p].token != "" && subtle.ConstantTimeCompare([]byte(ag.players[p].token), []byte(token)) == 1 {
+			player = p
+		}
+	}
+	if player == 0 {
+		writeError(w, http.StatusUnauthorized, errors.New("invalid token"))
+		return
+	}
+	if ag.players[3-player].token == "" {
+		writeError(w, http.StatusConflict, errors.New("waiting for an opponent to join"))
+		return
+	}
+
+	switch err := ag.game.Play(player, req.X, req.Y); err {
+	case nil:
+		metricMoves.Inc()
+		if ag.game.gameOver {
+			countGameEnded(ag.started, true)
+		}
+		ag.notifyInternal()
+		writeJSON(w, http.StatusOK, ag.viewInternal())
+	case ErrInvalidMove:
+		metricInvalidMoves.Inc()
+		writeError(w, http.StatusBadRequest, err)
+	default: // ErrNotYourTurn, ErrGameOver
+		metricInvalidMoves.Inc()
+		writeError(w, http.StatusConflict, err)
+	}
+}
+
+// Server-Sent Events: 连接时先推送一次当前状态, 之后每次变化推送一次
+func (h *GameHub) handleEvents(w http.ResponseWriter, r *http.Request, ag *apiGame) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	keepalive := time.NewTicker(apiSSEKeepalive)
+	defer keepalive.Stop()
+	for {
+		ag.mu.Lock()
+		view := ag.viewInternal()
+		changed, removed := ag.changed, ag.removed
+		ag.mu.Unlock()
+
+		data, _ := json.Marshal(view)
+		fmt.Fprintf(w, "id: %d\nevent: state\ndata: %s\n\n", view.Version, data)
+		flusher.Flush()
+		if view.GameOver || removed {
+			return
+		}
+
+		for waiting := true; waiting; {
+			select {
+			case <-changed:
+				waiting = false
+			case <-keepalive.C:
+				fmt.Fprint(w, ": keepalive\n\n") // 防止代理断开空闲连接
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
+
+// 在 addr 上提供 HTTP API; tlsConfig 非空时使用 HTTPS. 服务停止时返回原因
+func serveAPI(addr string, tlsConfig *tls.Config, hub *GameHub) error {
+	defer hub.Close()
+	slog.Info("HTTP API listening", "addr", addr)
+	server := &http.Server{Addr: addr, Handler: hub.Handler(), TLSConfig: tlsConfig}
+	var err error
+	if tlsConfig != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	return fmt.Errorf("HTTP API server stopped: %w", err)
+}
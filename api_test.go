@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 发送请求并解码 JSON 响应, 返回状态码
+func apiRequest(t *testing.T, h http.Handler, method, path, body string, v any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if v != nil {
+		if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestGameHubSweep(t *testing.T) {
+	hub := NewGameHub(nil)
+	defer hub.Close()
+	h := hub.Handler()
+	newGame := func(players int) string {
+		var view GameView
+		if code := apiRequest(t, h, "POST", "/api/games", "", &view); code != http.StatusCreated {
+			t.Fatalf("create: status %d", code)
+		}
+		for i := 0; i < players; i++ {
+			if code := apiRequest(t, h, "POST", "/api/games/"+view.ID+"/join", `{"user":"p"}`, nil); code != http.StatusOK {
+				t.Fatalf("join: status %d", code)
+			}
+		}
+		return view.ID
+	}
+
+	active, finished := metricActiveGames.v.Load(), metricGameDuration.count
+	waiting := newGame(1)
+	playing := newGame(2)
+	if got := metricActiveGames.v.Load(); got != active+1 {
+		t.Fatalf("active games = %d, want %d after one game started", got, active+1)
+	}
+
+	if open := hub.sweep(time.Now()); open != 2 {
+		t.Errorf("sweep right away: %d open games, want 2", open)
+	}
+	if open := hub.sweep(time.Now().Add(apiIdleTTL + time.Minute)); open != 0 {
+		t.Errorf("sweep after the idle TTL: %d open games, want 0", open)
+	}
+	for _, id := range []string{waiting, playing} {
+		if hub.get(id) != nil {
+			t.Errorf("game %s not removed after the idle TTL", id)
+		}
+	}
+	// 放弃的对局不再算作活跃, 也不计入 (下完的) 对局时长
+	if got := metricActiveGames.v.Load(); got != active {
+		t.Errorf("active games = %d after eviction, want %d", got, active)
+	}
+	if metricGameDuration.count != finished {
+		t.Errorf("abandoned game counted in tictactoe_game_duration_seconds")
+	}
+}
+
+func TestGameHubRequestLimits(t *testing.T) {
+	hub := NewGameHub(nil)
+	defer hub.Close()
+	h := hub.Handler()
+	var view GameView
+	apiRequest(t, h, "POST", "/api/games", "", &view)
+	body := `{"user":"` + strings.Repeat("x", apiMaxBody) + `"}`
+	if code := apiRequest(t, h, "POST", "/api/games/"+view.ID+"/join", body, nil); code != http.StatusBadRequest {
+		t.Errorf("join with a %d byte body: status %d, want %d", len(body), code, http.StatusBadRequest)
+	}
+	if code := apiRequest(t, h, "POST", "/api/games/nope/join", `{}`, nil); code != http.StatusNotFound {
+		t.Errorf("join unknown game: status %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+// Close 停止清理用的 goroutine, 重复调用无害
+func TestGameHubClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		hub := NewGameHub(nil)
+		hub.Close()
+		hub.Close()
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines left after closing the hubs, want %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,291 @@
+// auth.go
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	passwordIterations = 210000 // PBKDF2-SHA256 迭代次数
+	passwordSaltLen    = 16
+	passwordKeyLen     = 32
+	tokenLen           = 24               // 生成的预共享令牌字节数
+	loginTimeout       = 10 * time.Second // 等待客户端登录的最长时间
+)
+
+var ErrAuthFailed = errors.New("authentication failed")
+
+// 用户记录 (只保存哈希, 不保存明文)
+type UserRecord struct {
+	Name       string `json:"name"`
+	Salt       string `json:"salt,omitempty"`       // 十六进制编码的盐
+	Hash       string `json:"hash,omitempty"`       // PBKDF2 派生的密码哈希
+	Iterations int    `json:"iterations,omitempty"` // 派生时使用的迭代次数
+	TokenHash  string `json:"token_hash,omitempty"` // 预共享令牌的 SHA-256
+
+	TokenExpires time.Time `json:"token_expires,omitzero"` // 令牌的过期时间, 零值表示不过期
+}
+
+// 服务器端的用户存储, 对应 --users 指定的 JSON 文件
+type UserStore struct {
+	path  string
+	mu    sync.Mutex
+	Users []UserRecord `json:"users"`
+}
+
+// 加载用户文件 (文件不存在时返回空存储, 便于之后添加用户)
+func LoadUserStore(path string) (*UserStore, error) {
+	store := &UserStore{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return store, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("parse user file %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// 写回用户文件 (仅所有者可读写)
+func (s *UserStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+// 查找用户 (需要在外部加锁调用)
+func (s *UserStore) findInternal(name string) *UserRecord {
+	for i := range s.Users {
+		if s.Users[i].Name == name {
+			return &s.Users[i]
+		}
+	}
+	return nil
+}
+
+// 设置 (或重置) 用户密码
+func (s *UserStore) SetPassword(name, password string) error {
+	if name == "" || password == "" {
+		return fmt.Errorf("username and password must not be empty")
+	}
+	salt := make([]byte, passwordSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeyLen)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := s.findInternal(name)
+	if rec == nil {
+		s.Users = append(s.Users, UserRecord{Name: name})
+		rec = &s.Users[len(s.Users)-1]
+	}
+	rec.Salt = hex.EncodeToString(salt)
+	rec.Hash = hex.EncodeToString(key)
+	rec.Iterations = passwordIterations
+	return nil
+}
+
+// 为用户生成新的预共享令牌, 返回明文令牌 (只显示这一次); ttl 为 0 时令牌不过期
+func (s *UserStore) NewToken(name string, ttl time.Duration) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("username must not be empty")
+	}
+	raw := make([]byte, tokenLen)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+	sum := sha256.Sum256([]byte(token))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := s.findInternal(name)
+	if rec == nil {
+		s.Users = append(s.Users, UserRecord{Name: name})
+		rec = &s.Users[len(s.Users)-1]
+	}
+	rec.TokenHash = hex.EncodeToString(sum[:])
+	rec.TokenExpires = time.Time{}
+	if ttl > 0 {
+		rec.TokenExpires = time.Now().Add(ttl).UTC()
+	}
+	return token, nil
+}
+
+// 校验登录凭据, 密码和令牌任一匹配即可
+func (s *UserStore) Authenticate(name, password, token string) error {
+	s.mu.Lock()
+	rec := s.findInternal(name)
+	var user UserRecord
+	if rec != nil {
+		user = *rec
+	}
+	s.mu.Unlock()
+	if rec == nil {
+		return ErrAuthFailed
+	}
+
+	tokenValid := user.TokenExpires.IsZero() || time.Now().Before(user.TokenExpires)
+	if token != "" && user.TokenHash != "" && tokenValid {
+		sum := sha256.Sum256([]byte(token))
+		want, err := hex.DecodeString(user.TokenHash)
+		if err == nil && subtle.ConstantTimeCompare(sum[:], want) == 1 {
+			return nil
+		}
+	}
+	if password != "" && user.Hash != "" {
+		salt, errSalt := hex.DecodeString(user.Salt)
+		want, errHash := hex.DecodeString(user.Hash)
+		if errSalt != nil || errHash != nil {
+			return ErrAuthFailed
+		}
+		key, err := pbkdf2.Key(sha256.New, password, salt, user.Iterations, len(want))
+		if err == nil && subtle.ConstantTimeCompare(key, want) == 1 {
+			return nil
+		}
+	}
+	return ErrAuthFailed
+}
+
+// 客户端的登录请求, 校验通过后由主 goroutine 接受 (acceptLogin)
+type loginRequest struct {
+	conn  Transport
+	name  string // 清理过的用户名
+	hints bool   // 客户端同意使用 /hint
+}
+
+// 读取并校验一个连接的登录消息. 不修改 GameState, 可以对多个连接并发调用;
+// store 为 nil 时不校验密码, 只记录客户端提供的用户名; peerID 为连接方的玩家编号 (用于默认名字)
+func readLogin(t Transport, store *UserStore, peerID int) (loginRequest, error) {
+	t.SetReadDeadline(time.Now().Add(loginTimeout))
+	var msg Message
+	err := t.Receive(&msg)
+	t.SetReadDeadline(time.Time{}) // 登录后取消超时
+	if err != nil {
+		return loginRequest{}, fmt.Errorf("read login: %w", err)
+	}
+	slog.Debug("Received login", "remote", t.RemoteAddr(), "msg", msg) // LogValue 隐去了密码和令牌
+	if msg.Type != MsgTypeLogin {
+		t.Send(Message{Type: MsgTypeError, Content: "Login required"})
+		return loginRequest{}, fmt.Errorf("expected login message, got %q", msg.Type)
+	}
+
+	name := strings.TrimSpace(sanitizeText(msg.User)) // 用户名会显示在界面上
+	if store != nil {
+		if err := store.Authenticate(name, msg.Password, msg.Token); err != nil {
+			t.Send(Message{Type: MsgTypeError, Content: "Authentication failed"})
+			return loginRequest{}, fmt.Errorf("user %q: %w", name, err)
+		}
+	} else if name == "" {
+		name = fmt.Sprintf("Player %d", peerID)
+	}
+	return loginRequest{conn: t, name: name, hints: msg.Hints}, nil
+}
+
+// 等待第一个登录成功的客户端. 每个连接在自己的 goroutine 中登录, 连上后不发消息的客户端
+// 不会挡住其他人; 有人登录成功后, 还在登录的连接之后被拒绝. serverErr 收到错误时返回该错误
+func waitForLogin(incoming <-chan Transport, serverErr <-chan error, store *UserStore, peerID int) (loginRequest, error) {
+	logins := make(chan loginRequest)
+	done := make(chan struct{})
+	defer close(done)
+	for {
+		select {
+		case t := <-incoming:
+			go func() {
+				req, err := readLogin(t, store, peerID)
+				if err != nil {
+					slog.Warn("Login rejected", "remote", t.RemoteAddr(), "err", err)
+					metricLoginsRejected.Inc()
+					t.Close()
+					return
+				}
+				select {
+				case logins <- req:
+				case <-done:
+					t.Send(Message{Type: MsgTypeError, Content: "Game already in progress"})
+					t.Close()
+				}
+			}()
+		case req := <-logins:
+			return req, nil
+		case err := <-serverErr:
+			return loginRequest{}, err
+		}
+	}
+}
+
+// 接受校验过的登录: 这个连接成为对局的对端
+func (gs *GameState) acceptLogin(req loginRequest) {
+	gs.mu.Lock()
+	gs.conn = req.conn
+	gs.peerID = 3 - gs.playerID // 连接的对端固定为另一方, 之后忽略消息中的 Player 字段
+	gs.peerName = req.name
+	gs.hintsOK = gs.allowHints && req.hints // 双方都同意才可以 /hint, 在分配消息中告诉客户端
+	gs.mu.Unlock()
+}
+
+// 客户端: 连接建立后发送登录消息
+func (gs *GameState) sendLogin(password, token string) error {
+	msg := Message{
+		Type:     MsgTypeLogin,
+		User:     gs.userName,
+		Password: password,
+		Token:    token,
+		Hints:    gs.allowHints,
+	}
+	slog.Debug("Sending login", "msg", msg)
+	return gs.conn.Send(msg)
+}
+
+// 处理 --add-user / --add-token, 修改用户文件后退出
+func manageUsers(path, addUser, addToken string, tokenTTL time.Duration) error {
+	if path == "" {
+		return fmt.Errorf("--users <file> is required")
+	}
+	store, err := LoadUserStore(path)
+	if err != nil {
+		return err
+	}
+	if addUser != "" {
+		fmt.Print(T("users.password_prompt", addUser))
+		password, err := stdinReader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err := store.SetPassword(addUser, strings.TrimSpace(password)); err != nil {
+			return err
+		}
+		fmt.Println(T("users.password_set", addUser))
+	}
+	if addToken != "" {
+		token, err := store.NewToken(addToken, tokenTTL)
+		if err != nil {
+			return err
+		}
+		fmt.Println(T("users.token", addToken, token))
+	}
+	return store.Save()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUserStoreAuthenticate(t *testing.T) {
+	store := &UserStore{path: filepath.Join(t.TempDir(), "users.json")}
+	if err := store.SetPassword("alice", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	token, err := store.NewToken("bob", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetPassword("", "x"); err == nil {
+		t.Error("SetPassword with an empty username succeeded, want error")
+	}
+
+	tests := []struct {
+		name, user, password, token string
+		ok                          bool
+	}{
+		{"right password", "alice", "s3cret", "", true},
+		{"wrong password", "alice", "secret", "", false},
+		{"no credentials", "alice", "", "", false},
+		{"unknown user", "carol", "s3cret", "", false},
+		{"right token", "bob", "", token, true},
+		{"wrong token", "bob", "", token[1:] + "0", false},
+		{"token of another user", "alice", "", token, false},
+	}
+	for _, tt := range tests {
+		err := store.Authenticate(tt.user, tt.password, tt.token)
+		if tt.ok && err != nil {
+			t.Errorf("%s: Authenticate = %v, want nil", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrAuthFailed) {
+			t.Errorf("%s: Authenticate = %v, want ErrAuthFailed", tt.name, err)
+		}
+	}
+
+	// 保存后重新加载, 只留下哈希, 仍然可以登录
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadUserStore(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Authenticate("alice", "s3cret", ""); err != nil {
+		t.Errorf("Authenticate after reload = %v, want nil", err)
+	}
+	if err := loaded.Authenticate("bob", "", token); err != nil {
+		t.Errorf("Authenticate with token after reload = %v, want nil", err)
+	}
+}
+
+func TestUserStoreTokenExpiry(t *testing.T) {
+	store := &UserStore{}
+	token, err := store.NewToken("bob", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Authenticate("bob", "", token); err != nil {
+		t.Fatalf("Authenticate with a fresh token = %v, want nil", err)
+	}
+	store.Users[0].TokenExpires = time.Now().Add(-time.Minute)
+	if err := store.Authenticate("bob", "", token); !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("Authenticate with an expired token = %v, want ErrAuthFailed", err)
+	}
+
+	// 重新生成不过期的令牌, 旧令牌失效
+	fresh, err := store.NewToken("bob", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !store.Users[0].TokenExpires.IsZero() {
+		t.Errorf("NewToken without a TTL left expiry %v", store.Users[0].TokenExpires)
+	}
+	if err := store.Authenticate("bob", "", token); !errors.Is(err, ErrAuthFailed) {
+		t.Error("the replaced token still authenticates")
+	}
+	if err := store.Authenticate("bob", "", fresh); err != nil {
+		t.Errorf("Authenticate with the new token = %v, want nil", err)
+	}
+}
+
+// 有用户文件时, 同一个用户不能同时占两个席位
+func TestGameHubRejectsSameUserTwice(t *testing.T) {
+	store := &UserStore{}
+	tokens := map[string]string{}
+	for _, user := range []string{"alice", "bob"} {
+		token, err := store.NewToken(user, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[user] = token
+	}
+	hub := NewGameHub(store)
+	defer hub.Close()
+	h := hub.Handler()
+	var view GameView
+	apiRequest(t, h, "POST", "/api/games", "", &view)
+	join := func(user string) int {
+		return apiRequest(t, h, "POST", "/api/games/"+view.ID+"/join", `{"user":"`+user+`","token":"`+tokens[user]+`"}`, nil)
+	}
+	if code := join("alice"); code != http.StatusOK {
+		t.Fatalf("alice joins: status %d", code)
+	}
+	if code := join("alice"); code != http.StatusConflict {
+		t.Errorf("alice joins again: status %d, want %d", code, http.StatusConflict)
+	}
+	if code := join("bob"); code != http.StatusOK {
+		t.Errorf("bob joins: status %d, want %d", code, http.StatusOK)
+	}
+}
+
+// 连上后不发消息的客户端不会挡住其他人登录; 有人登录成功后, 它再登录会被拒绝
+func TestWaitForLoginNotBlockedBySilentClient(t *testing.T) {
+	incoming := make(chan Transport, 2)
+	connect := func() Transport {
+		server, client := net.Pipe()
+		t.Cleanup(func() { client.Close() })
+		incoming <- NewJSONTransport(server)
+		return NewJSONTransport(client)
+	}
+	silent := connect()
+	bob := connect()
+	go bob.Send(Message{Type: MsgTypeLogin, User: "bob", Hints: true})
+
+	type result struct {
+		req loginRequest
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		req, err := waitForLogin(incoming, nil, nil, Player2)
+		done <- result{req, err}
+	}()
+	select {
+	case r := <-done:
+		if r.err != nil || r.req.name != "bob" || !r.req.hints {
+			t.Fatalf("waitForLogin = %+v, %v; want bob with hints", r.req, r.err)
+		}
+	case <-time.After(loginTimeout / 2):
+		t.Fatal("waitForLogin blocked behind the silent client")
+	}
+
+	go silent.Send(Message{Type: MsgTypeLogin, User: "late"})
+	var msg Message
+	if err := silent.Receive(&msg); err != nil || msg.Type != MsgTypeError || msg.Content != "Game already in progress" {
+		t.Errorf("late login got %+v, %v; want the game-in-progress error", msg, err)
+	}
+}
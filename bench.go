@@ -0,0 +1,91 @@
+// bench.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const benchDepth = 6 // bench 子命令和 BenchmarkSearch 的搜索深度
+
+// 固定的中局局面, 用于比较搜索速度
+var benchPositions = []string{
+	"h8 i9 g9 i7 i8 j8 h10 k9 h6 l10 m11 i11",
+	"h8 h9 g9 f10 f8 g8 h10 i11 i10 j10 h12 e7",
+	"h8 j10 h10 h9 g9 i11 f10 e11 g10 e10 g11 g8",
+	"h8 g9 i7 h10 f8 g8 g7 i9 h6 e9",
+	"h8 i9 g9 i7 i8 j8 h10 k9 h6 l10 m11 i11 h9 h7 j7 k6",
+}
+
+// benchPositions 的棋盘和轮到的一方 (bench 子命令和 BenchmarkSearch 共用)
+func benchBoards() (boards [][][]int, players []int, err error) {
+	for i, text := range benchPositions {
+		moves, _, err := ParseMoveText(text)
+		if err != nil {
+			return nil, nil, fmt.Errorf("bench position %d: %w", i+1, err)
+		}
+		g := NewGame()
+		for _, m := range moves {
+			if err := g.Play(m.Player, m.X, m.Y); err != nil {
+				return nil, nil, fmt.Errorf("bench position %d: %w", i+1, err)
+			}
+		}
+		boards, players = append(boards, g.board), append(players, g.currentPlayer)
+	}
+	return boards, players, nil
+}
+
+// tictactoe bench: 用不同的线程数搜索同一组局面, 打印节点数, 每秒节点数,
+// 以及搜到同样深度所用时间相对第一行的加速比
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	depth := fs.Int("depth", benchDepth, "Search depth")
+	width := fs.Int("width", aiDefaultWidth, "Candidate moves per node")
+	threadList := fs.String("threads", "", "Comma-separated thread counts to compare (default 1 and powers of two up to GOMAXPROCS)")
+	lang := fs.String("lang", "", "Interface language, e.g. en or zh-CN (default from $LC_ALL, $LC_MESSAGES or $LANG)")
+	fs.Parse(args)
+	SetLanguage(*lang)
+
+	var counts []int
+	if *threadList == "" {
+		for n := 1; n <= runtime.GOMAXPROCS(0); n *= 2 {
+			counts = append(counts, n)
+		}
+	} else {
+		for _, f := range strings.Split(*threadList, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(f))
+			if err != nil || n < 1 {
+				log.Fatalf("bench: invalid thread count %q", f)
+			}
+			counts = append(counts, n)
+		}
+	}
+	boards, players, err := benchBoards()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(T("bench.header", len(boards), *depth, *width))
+	fmt.Printf("%7s %12s %10s %12s %8s\n", T("bench.col_threads"), T("bench.col_nodes"), T("bench.col_time"),
+		T("bench.col_nps"), T("bench.col_speedup"))
+	var base time.Duration
+	for _, n := range counts {
+		nodes := 0
+		start := time.Now()
+		for i, board := range boards {
+			ai := &AI{Depth: *depth, Width: *width, Threads: n} // 每个局面用新的置换表
+			nodes += ai.Search(board, players[i]).Nodes
+		}
+		elapsed := time.Since(start)
+		if base == 0 {
+			base = elapsed
+		}
+		fmt.Printf("%7d %12d %10s %12.0f %7.2fx\n", n, nodes, elapsed.Round(time.Millisecond),
+			float64(nodes)/elapsed.Seconds(), base.Seconds()/elapsed.Seconds())
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// 与 bench 子命令相同的局面和深度, 按线程数分组:
+//
+//	go test -run '^$' -bench Search -benchtime 3x
+//
+// nodes/s 一栏对应子命令的每秒节点数
+func BenchmarkSearch(b *testing.B) {
+	boards, players, err := benchBoards()
+	if err != nil {
+		b.Fatal(err)
+	}
+	counts := []int{1}
+	for n := 2; n <= max(runtime.GOMAXPROCS(0), 4); n *= 2 {
+		counts = append(counts, n)
+	}
+	for _, threads := range counts {
+		b.Run(fmt.Sprintf("threads=%d", threads), func(b *testing.B) {
+			nodes := 0
+			for b.Loop() {
+				for i, board := range boards {
+					ai := &AI{Depth: benchDepth, Width: aiDefaultWidth, Threads: threads} // 每个局面用新的置换表
+					nodes += ai.Search(board, players[i]).Nodes
+				}
+			}
+			b.ReportMetric(float64(nodes)/b.Elapsed().Seconds(), "nodes/s")
+			b.ReportMetric(float64(nodes)/float64(b.N), "nodes/op")
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// bitboard.go
+package main
+
+import (
+	"math/bits"
+	"math/rand/v2"
+)
+
+// 位棋盘: 每方一个 225 位的位集, 带增量更新的 Zobrist 哈希.
+// 是值类型, 赋值就是复制 (共 104 字节), 适合搜索中频繁保存局面;
+// 与 [][]int 棋盘之间用 BitboardFrom 和 Grid 转换
+
+const boardCells = BoardSize * BoardSize
+
+type bitset [(boardCells + 63) / 64]uint64
+
+func (s *bitset) has(i int) bool { return s[i>>6]&(1<<(i&63)) != 0 }
+func (s *bitset) set(i int)      { s[i>>6] |= 1 << (i & 63) }
+func (s *bitset) clear(i int)    { s[i>>6] &^= 1 << (i & 63) }
+
+// 依次对每个置位的下标调用 fn
+func (s *bitset) each(fn func(i int)) {
+	for w, word := range s {
+		for word != 0 {
+			fn(w*64 + bits.TrailingZeros64(word))
+			word &= word - 1
+		}
+	}
+}
+
+// 零值是空棋盘
+type Bitboard struct {
+	stones [3]bitset // 下标为玩家编号, 0 不用
+	hash   uint64
+}
+
+// 每个交叉点每种棋子一个随机数, 局面的哈希是所有棋子的异或; 种子固定, 同一局面每次运行的哈希相同
+var (
+	zobristKeys [boardCells][3]uint64
+	zobristSide [3]uint64 // 行棋方, 用于区分同一局面轮到不同的人
+)
+
+func init() {
+	r := rand.New(rand.NewPCG(0x5eed, 0x90b0))
+	for i := range zobristKeys {
+		zobristKeys[i][Player1] = r.Uint64()
+		zobristKeys[i][Player2] = r.Uint64()
+	}
+	zobristSide[Player1], zobristSide[Player2] = r.Uint64(), r.Uint64()
+}
+
+// 从 [][]int 棋盘转换
+func BitboardFrom(board [][]int) Bitboard {
+	var b Bitboard
+	for x := range board {
+		for y, p := range board[x] {
+			if p != Empty {
+				b.Place(x, y, p)
+			}
+		}
+	}
+	return b
+}
+
+func (b *Bitboard) At(x, y int) int {
+	i := x*BoardSize + y
+	switch {
+	case b.stones[Player1].has(i):
+		return Player1
+	case b.stones[Player2].has(i):
+		return Player2
+	}
+	return Empty
+}
+
+// 在空位 (x, y) 放一子; 调用方保证该点为空
+func (b *Bitboard) Place(x, y, player int) {
+	i := x*BoardSize + y
+	b.stones[player].set(i)
+	b.hash ^= zobristKeys[i][player]
+}
+
+// 拿掉 (x, y) 的棋子, 没有棋子时不变
+func (b *Bitboard) Remove(x, y int) {
+	if p := b.At(x, y); p != Empty {
+		i := x*BoardSize + y
+		b.stones[p].clear(i)
+		b.hash ^= zobristKeys[i][p]
+	}
+}
+
+// 局面的 Zobrist 哈希 (不含行棋方)
+func (b *Bitboard) Hash() uint64 { return b.hash }
+
+// player 的棋子数
+func (b *Bitboard) Count(player int) int {
+	n := 0
+	for _, w := range b.stones[player] {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// 转换成 [][]int 棋盘, 供现有的函数使用
+func (b *Bitboard) Grid() [][]int {
+	board := NewBoard(BoardSize)
+	for _, p := range []int{Player1, Player2} {
+		b.stones[p].each(func(i int) { board[i/BoardSize][i%BoardSize] = p })
+	}
+	return board
+}
+
+// --- 对称 ---
+
+// 正方形棋盘的 8 种对称 (旋转和翻转): 先按 bit 2 转置, 再按 bit 0 上下翻转, bit 1 左右翻转.
+// 0 是恒等变换
+type Symmetry uint8
+
+const symmetryCount = 8
+
+func (s Symmetry) Apply(x, y int) (int, int) {
+	const last = BoardSize - 1
+	if s&4 != 0 {
+		x, y = y, x
+	}
+	if s&1 != 0 {
+		x = last - x
+	}
+	if s&2 != 0 {
+		y = last - y
+	}
+	return x, y
+}
+
+// 逆变换: 不转置时翻转是自身的逆; 转置时两个翻转的方向互换
+func (s Symmetry) Inverse() Symmetry {
+	if s&4 == 0 {
+		return s
+	}
+	return 4 | (s&1)<<1 | (s&2)>>1
+}
+
+// 按 s 变换后的局面
+func (b *Bitboard) Transform(s Symmetry) Bitboard {
+	var t Bitboard
+	for _, p := range []int{Player1, Player2} {
+		b.stones[p].each(func(i int) {
+			x, y := s.Apply(i/BoardSize, i%BoardSize)
+			t.Place(x, y, p)
+		})
+	}
+	return t
+}
+
+// 规范形式: 8 种变换中哈希最小的一个. 互为对称的局面得到同样的哈希;
+// sym 把本局面变换到规范形式, 坐标用 sym.Apply 换过去, 用 sym.Inverse().Apply 换回来
+func (b *Bitboard) Canonical() (hash uint64, sym Symmetry) {
+	var hashes [symmetryCount]uint64
+	for _, p := range []int{Player1, Player2} {
+		b.stones[p].each(func(i int) {
+			for s := range hashes {
+				x, y := Symmetry(s).Apply(i/BoardSize, i%BoardSize)
+				hashes[s] ^= zobristKeys[x*BoardSize+y][p]
+			}
+		})
+	}
+	hash = hashes[0]
+	for s, h := range hashes {
+		if h < hash {
+			hash, sym = h, Symmetry(s)
+		}
+	}
+	return hash, sym
+}
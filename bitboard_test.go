@@ -0,0 +1,130 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// 落子和提子时哈希增量更新, 与从头计算的结果一致
+func TestBitboardHash(t *testing.T) {
+	var b Bitboard
+	if b.Hash() != 0 {
+		t.Fatal("hash of the empty board is not 0")
+	}
+	board := NewBoard(BoardSize)
+	for i, m := range []Move{{Player1, 7, 7}, {Player2, 6, 8}, {Player1, 0, 14}, {Player2, 14, 0}} {
+		b.Place(m.X, m.Y, m.Player)
+		board[m.X][m.Y] = m.Player
+		if full := BitboardFrom(board); b.Hash() != full.Hash() {
+			t.Fatalf("after move %d: incremental hash %x, BitboardFrom %x", i+1, b.Hash(), full.Hash())
+		}
+	}
+	h := b.Hash()
+	b.Remove(6, 8)
+	b.Place(6, 8, Player1) // 同一点换成另一方的棋子, 哈希不同
+	if b.Hash() == h {
+		t.Error("hash does not depend on the stone's owner")
+	}
+	b.Remove(6, 8)
+	b.Remove(6, 8) // 没有棋子时不变
+	b.Place(6, 8, Player2)
+	if b.Hash() != h {
+		t.Errorf("hash after remove and place = %x, want %x", b.Hash(), h)
+	}
+}
+
+// 一个不对称的局面, 8 种变换后各不相同
+var asymmetricMoves = []Move{{Player1, 7, 7}, {Player2, 6, 8}, {Player1, 5, 8}, {Player2, 0, 3}, {Player1, 14, 1}}
+
+func TestBitboardGrid(t *testing.T) {
+	board := boardWith(asymmetricMoves...)
+	b := BitboardFrom(board)
+	if !reflect.DeepEqual(b.Grid(), board) {
+		t.Errorf("BitboardFrom(board).Grid() differs from board")
+	}
+	for x := range board {
+		for y := range board[x] {
+			if b.At(x, y) != board[x][y] {
+				t.Fatalf("At(%d, %d) = %d, want %d", x, y, b.At(x, y), board[x][y])
+			}
+		}
+	}
+	if b.Count(Player1) != 3 || b.Count(Player2) != 2 {
+		t.Errorf("Count = %d, %d; want 3, 2", b.Count(Player1), b.Count(Player2))
+	}
+}
+
+func TestSymmetry(t *testing.T) {
+	seen := make(map[[2]int]bool)
+	for s := Symmetry(0); s < symmetryCount; s++ {
+		inv := s.Inverse()
+		for x := 0; x < BoardSize; x++ {
+			for y := 0; y < BoardSize; y++ {
+				tx, ty := s.Apply(x, y)
+				if tx < 0 || tx >= BoardSize || ty < 0 || ty >= BoardSize {
+					t.Fatalf("symmetry %d maps (%d, %d) off the board", s, x, y)
+				}
+				if bx, by := inv.Apply(tx, ty); bx != x || by != y {
+					t.Fatalf("symmetry %d inverse %d maps (%d, %d) back to (%d, %d)", s, inv, x, y, bx, by)
+				}
+			}
+		}
+		// 8 种变换各不相同: 看 (0, 1) 被换到哪里
+		x, y := s.Apply(0, 1)
+		if seen[[2]int{x, y}] {
+			t.Errorf("symmetry %d duplicates another symmetry", s)
+		}
+		seen[[2]int{x, y}] = true
+		// 天元不动
+		if x, y := s.Apply(BoardSize/2, BoardSize/2); x != BoardSize/2 || y != BoardSize/2 {
+			t.Errorf("symmetry %d moves the centre to (%d, %d)", s, x, y)
+		}
+	}
+	if x, y := Symmetry(0).Apply(3, 4); x != 3 || y != 4 {
+		t.Errorf("symmetry 0 is not the identity")
+	}
+}
+
+// 局面的 8 种变换得到同样的规范哈希, 且 sym 确实把每个局面换到同一个规范局面
+func TestCanonical(t *testing.T) {
+	b := BitboardFrom(boardWith(asymmetricMoves...))
+	want, _ := b.Canonical()
+	hashes := make(map[uint64]bool)
+	for s := Symmetry(0); s < symmetryCount; s++ {
+		tb := b.Transform(s)
+		hashes[tb.Hash()] = true
+		if tb.Count(Player1) != b.Count(Player1) || tb.Count(Player2) != b.Count(Player2) {
+			t.Fatalf("Transform(%d) changed the stone count", s)
+		}
+		hash, sym := tb.Canonical()
+		if hash != want {
+			t.Errorf("Transform(%d).Canonical() = %x, want %x", s, hash, want)
+		}
+		if c := tb.Transform(sym); c.Hash() != hash {
+			t.Errorf("Transform(%d): applying sym %d gives hash %x, want canonical %x", s, sym, c.Hash(), hash)
+		}
+		// 规范形式下的坐标换回原局面仍是同一颗棋子
+		for _, m := range asymmetricMoves {
+			x, y := s.Apply(m.X, m.Y)
+			cx, cy := sym.Apply(x, y)
+			if bx, by := sym.Inverse().Apply(cx, cy); bx != x || by != y || tb.At(x, y) != m.Player {
+				t.Errorf("Transform(%d): %v does not round-trip through sym %d", s, m, sym)
+			}
+		}
+	}
+	if len(hashes) != symmetryCount {
+		t.Errorf("the asymmetric position has %d distinct transforms, want %d", len(hashes), symmetryCount)
+	}
+
+	// 对称的局面: 只有天元一子时 8 种变换都相同
+	var centre Bitboard
+	centre.Place(BoardSize/2, BoardSize/2, Player1)
+	if hash, _ := centre.Canonical(); hash != centre.Hash() {
+		t.Errorf("centre stone: Canonical() = %x, want its own hash %x", hash, centre.Hash())
+	}
+	// 不同的局面规范哈希不同
+	other := BitboardFrom(boardWith(asymmetricMoves[:4]...))
+	if hash, _ := other.Canonical(); hash == want {
+		t.Error("different positions share a canonical hash")
+	}
+}
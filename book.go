@@ -0,0 +1,347 @@
+// book.go
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 开局库: 每个局面的候选着法及权重, 供 AI 开局时选用, 也用于给引擎对局生成开局.
+//
+// 文件每行是一个落子序列, 后面可以跟一个整数权重 (默认 1), # 之后是注释:
+//
+//	h8 i9 j10 12    # 9 games +6 =2 -1
+//
+// 权重属于序列的最后一手, 前面的落子只用来确定局面; 局面按棋盘上的棋子比较,
+// 所以不同顺序走到同一局面, 以及互为旋转或翻转的局面会合并. 前面的落子没有单独列出时以权重 1 加入,
+// 手写的开局库只列出完整的变化即可
+
+// 开局库中的一手
+type BookMove struct {
+	X, Y   int
+	Weight int
+}
+
+type OpeningBook struct {
+	positions map[uint64][]BookMove // 规范局面的哈希 -> 候选着法 (规范局面中的坐标)
+}
+
+func NewOpeningBook() *OpeningBook {
+	return &OpeningBook{positions: make(map[uint64][]BookMove)}
+}
+
+// 在 moves 之后的局面中加入 m; 已有这一手时, accumulate 为 true 则累加权重, 否则不变
+func (b *OpeningBook) add(moves []Move, m Move, weight int, accumulate bool) {
+	var bb Bitboard
+	for _, p := range moves {
+		bb.Place(p.X, p.Y, p.Player)
+	}
+	key, x, y := canonicalMove(&bb, m.X, m.Y)
+	list := b.positions[key]
+	for i := range list {
+		if list[i].X == x && list[i].Y == y {
+			if accumulate {
+				list[i].Weight += weight
+			}
+			return
+		}
+	}
+	b.positions[key] = append(list, BookMove{X: x, Y: y, Weight: weight})
+}
+
+// 局面的规范哈希, 以及 (x, y) 在规范局面中的坐标. 局面本身对称时 (例如只有天元一子)
+// 有几种变换都得到规范形式, 互为对称的着法 (天元旁的 i9 和 g7) 取其中最小的坐标, 合并为一手
+func canonicalMove(bb *Bitboard, x, y int) (key uint64, cx, cy int) {
+	key, sym := bb.Canonical()
+	cx, cy = sym.Apply(x, y)
+	for s := Symmetry(0); s < symmetryCount; s++ {
+		if s == sym {
+			continue
+		}
+		if t := bb.Transform(s); t.Hash() == key {
+			if tx, ty := s.Apply(x, y); tx*BoardSize+ty < cx*BoardSize+cy {
+				cx, cy = tx, ty
+			}
+		}
+	}
+	return key, cx, cy
+}
+
+// 加入一个序列, 权重属于最后一手
+func (b *OpeningBook) AddLine(moves []Move, weight int) {
+	for i, m := range moves {
+		if i == len(moves)-1 {
+			b.add(moves[:i], m, weight, true)
+		} else {
+			b.add(moves[:i], m, 1, false)
+		}
+	}
+}
+
+// 局面中的候选着法 (换算到 board 的坐标); 不在库中时返回 nil
+func (b *OpeningBook) Moves(board [][]int) []BookMove {
+	if b == nil {
+		return nil
+	}
+	bb := BitboardFrom(board)
+	key, sym := bb.Canonical()
+	list := b.positions[key]
+	if len(list) == 0 {
+		return nil
+	}
+	inv := sym.Inverse()
+	moves := make([]BookMove, len(list))
+	for i, m := range list {
+		x, y := inv.Apply(m.X, m.Y)
+		moves[i] = BookMove{X: x, Y: y, Weight: m.Weight}
+	}
+	return moves
+}
+
+// 按权重随机选择一手; 局面不在库中或权重都为 0 时 ok 为 false
+func (b *OpeningBook) Pick(board [][]int) (x, y int, ok bool) {
+	total := 0
+	list := b.Moves(board)
+	for _, m := range list {
+		total += m.Weight
+	}
+	if total <= 0 {
+		return 0, 0, false
+	}
+	r := rand.IntN(total)
+	for _, m := range list {
+		if r -= m.Weight; r < 0 {
+			// 哈希碰撞时落点可能已有棋子, 这时放弃
+			return m.X, m.Y, board[m.X][m.Y] == Empty
+		}
+	}
+	return 0, 0, false
+}
+
+// 从空棋盘开始按权重随机走 plies 手, 库中没有后续时提前结束
+func (b *OpeningBook) RandomLine(plies int) []Move {
+	g := NewGame()
+	for len(g.moves) < plies && !g.gameOver {
+		x, y, ok := b.Pick(g.board)
+		if !ok || g.Play(g.currentPlayer, x, y) != nil {
+			break
+		}
+	}
+	return g.Moves()
+}
+
+// 库中的局面数
+func (b *OpeningBook) Len() int { return len(b.positions) }
+
+func LoadOpeningBook(r io.Reader) (*OpeningBook, error) {
+	b := NewOpeningBook()
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		weight := 1
+		if w, err := strconv.Atoi(fields[len(fields)-1]); err == nil { // 坐标不会是纯数字
+			if w < 0 {
+				return nil, fmt.Errorf("line %d: negative weight %d", n, w)
+			}
+			weight, fields = w, fields[:len(fields)-1]
+		}
+		moves, _, err := ParseMoveText(strings.Join(fields, " "))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if len(moves) == 0 {
+			return nil, fmt.Errorf("line %d: no moves", n)
+		}
+		b.AddLine(moves, weight)
+	}
+	return b, scanner.Err()
+}
+
+// 读取开局库文件 (或 "-" 表示标准输入)
+func LoadOpeningBookFile(path string) (*OpeningBook, error) {
+	var b *OpeningBook
+	err := withInputFile(path, func(r io.Reader) (err error) {
+		b, err = LoadOpeningBook(r)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("opening book %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// 引擎参数 book=FILE 读入的开局库, 按路径缓存: 锦标赛每局都会新建引擎,
+// 同一个文件只读一次 (book=- 的标准输入也只能读一次), 各引擎共用只读的 *OpeningBook
+var openingBookCache = struct {
+	sync.Mutex
+	books map[string]*OpeningBook
+}{books: make(map[string]*OpeningBook)}
+
+func sharedOpeningBook(path string) (*OpeningBook, error) {
+	openingBookCache.Lock()
+	defer openingBookCache.Unlock()
+	if b, ok := openingBookCache.books[path]; ok {
+		return b, nil
+	}
+	b, err := LoadOpeningBookFile(path)
+	if err != nil {
+		return nil, err
+	}
+	openingBookCache.books[path] = b
+	return b, nil
+}
+
+// --- 从对局记录生成开局库 ---
+
+// 某个局面下一手的统计, 从落子一方的角度
+type bookStat struct {
+	moves               []Move // 到这一手为止的序列 (第一次见到时的顺序)
+	wins, draws, losses int
+}
+
+func (s *bookStat) games() int { return s.wins + s.draws + s.losses }
+
+// tictactoe book [选项] 记录文件或目录...: 统计对局记录 (例如 tournament --save 的目录)
+// 前若干手的着法, 输出开局库. 权重是落子一方的得分 (胜 2, 和 1)
+func runBook(args []string) {
+	fs := flag.NewFlagSet("book", flag.ExitOnError)
+	plies := fs.Int("plies", 8, "Number of opening moves to take from each game")
+	minGames := fs.Int("min-games", 2, "Leave out moves played in fewer games")
+	out := fs.String("o", "", "Write the book to this file instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: tictactoe book [options] record-file|directory...")
+		fmt.Fprintln(fs.Output(), "Builds an opening book from finished game records; directories are searched for *.txt files.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var files []string
+	for _, arg := range fs.Args() {
+		info, err := os.Stat(arg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(arg, "*.txt"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		files = append(files, matches...)
+	}
+
+	var records []GameRecord
+	for _, path := range files {
+		var rec GameRecord
+		err := withInputFile(path, func(r io.Reader) (err error) {
+			rec, err = ReadRecord(r)
+			return err
+		})
+		if err != nil {
+			slog.Warn("Skipping record", "file", path, "err", err)
+			continue
+		}
+		records = append(records, rec)
+	}
+	list, used := bookStats(records, *plies, *minGames)
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# Opening book built from %d games, first %d moves\n", used, *plies)
+	for _, s := range list {
+		var coords []string
+		for _, m := range s.moves {
+			coords = append(coords, NotationAlgebraic.Format(m.X, m.Y))
+		}
+		fmt.Fprintf(bw, "%s %d  # %d games +%d =%d -%d\n", strings.Join(coords, " "), 2*s.wins+s.draws,
+			s.games(), s.wins, s.draws, s.losses)
+	}
+	if err := bw.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	slog.Info("Wrote opening book", "moves", len(list), "games", used)
+}
+
+// 统计对局记录前 plies 手的着法, 对称的变化合并在一起. 返回至少 minGames 局走过并且有得分的着法
+// (先按手数, 同一手数内按局数排列) 和统计了的对局数; 没有结果和让子的对局不统计
+func bookStats(records []GameRecord, plies, minGames int) (list []*bookStat, used int) {
+	type statKey struct {
+		position uint64
+		x, y     int
+	}
+	stats := make(map[statKey]*bookStat)
+	var order []statKey
+	for _, rec := range records {
+		if rec.Winner == 0 || rec.Handicap != (Handicap{}) {
+			continue
+		}
+		used++
+		g := NewGame()
+		var bb Bitboard
+		for i, m := range rec.Moves {
+			if i >= plies {
+				break
+			}
+			hash, x, y := canonicalMove(&bb, m.X, m.Y)
+			key := statKey{hash, x, y}
+			s, ok := stats[key]
+			if !ok {
+				s = &bookStat{moves: append(g.Moves(), m)}
+				stats[key] = s
+				order = append(order, key)
+			}
+			switch rec.Winner {
+			case Draw:
+				s.draws++
+			case m.Player:
+				s.wins++
+			default:
+				s.losses++
+			}
+			g.Play(m.Player, m.X, m.Y) // ReadRecord 已经校验过
+			bb.Place(m.X, m.Y, m.Player)
+		}
+	}
+
+	for _, key := range order {
+		if s := stats[key]; s.games() >= minGames && 2*s.wins+s.draws > 0 {
+			list = append(list, s)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if len(list[i].moves) != len(list[j].moves) {
+			return len(list[i].moves) < len(list[j].moves)
+		}
+		return list[i].games() > list[j].games()
+	})
+	return list, used
+}
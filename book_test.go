@@ -0,0 +1,212 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 在空棋盘上依次落子
+func gameWith(t *testing.T, moves ...Move) *Game {
+	t.Helper()
+	g := NewGame()
+	for _, m := range moves {
+		if err := g.Play(m.Player, m.X, m.Y); err != nil {
+			t.Fatalf("play %v: %v", m, err)
+		}
+	}
+	return g
+}
+
+func TestLoadOpeningBook(t *testing.T) {
+	book, err := LoadOpeningBook(strings.NewReader(`# 手写的开局库
+h8 i9 2     # 权重属于最后一手
+h8 i9 j10 4
+
+h8 h9 0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h8, i9 := Move{Player1, 7, 7}, Move{Player2, 6, 8}
+	// 前面的落子以权重 1 加入, 之后再出现也不累加
+	if got, want := book.Moves(NewBoard(BoardSize)), []BookMove{{7, 7, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Moves(empty) = %v, want %v", got, want)
+	}
+	// 只有天元一子时四个斜向的点等价 (i9 记为 g9), 四个正向的点也等价
+	got := book.Moves(gameWith(t, h8).board)
+	want := []BookMove{{6, 6, 2}, {6, 7, 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Moves(h8) = %v, want %v", got, want)
+	}
+	if got, want := book.Moves(gameWith(t, h8, i9).board), []BookMove{{5, 9, 4}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Moves(h8 i9) = %v, want %v", got, want)
+	}
+	if book.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", book.Len())
+	}
+
+	for _, bad := range []string{
+		"h8 -1\n", // 负的权重
+		"h8 h8\n", // 落在已有棋子上
+		"3\n",     // 只有权重
+		"h8 zz\n", // 不是坐标
+	} {
+		if _, err := LoadOpeningBook(strings.NewReader(bad)); err == nil {
+			t.Errorf("LoadOpeningBook(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestOpeningBookSymmetry(t *testing.T) {
+	book := NewOpeningBook()
+	// 天元之后的 i9 和 g7 互为旋转, 合并为一手
+	book.AddLine([]Move{{Player1, 7, 7}, {Player2, 6, 8}}, 2)
+	book.AddLine([]Move{{Player1, 7, 7}, {Player2, 8, 6}}, 3)
+	if got := book.Moves(gameWith(t, Move{Player1, 7, 7}).board); len(got) != 1 || got[0].Weight != 5 {
+		t.Errorf("Moves after the centre = %v, want one move of weight 5", got)
+	}
+
+	// 不同顺序走到同一局面也合并
+	book.AddLine([]Move{{Player1, 7, 7}, {Player2, 6, 8}, {Player1, 7, 8}, {Player2, 5, 9}}, 1)
+	book.AddLine([]Move{{Player1, 7, 8}, {Player2, 6, 8}, {Player1, 7, 7}, {Player2, 5, 9}}, 1)
+	g := gameWith(t, Move{Player1, 7, 8}, Move{Player2, 6, 8}, Move{Player1, 7, 7})
+	if got, want := book.Moves(g.board), []BookMove{{5, 9, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Moves after a transposition = %v, want %v", got, want)
+	}
+
+	// 库中记的是角上的局面, 查询它旋转和转置后的局面时换算回查询棋盘的坐标
+	book.AddLine([]Move{{Player1, 0, 0}, {Player2, 0, 1}, {Player1, 1, 1}}, 1)
+	last := BoardSize - 1
+	for _, tt := range []struct {
+		moves []Move
+		want  BookMove
+	}{
+		{[]Move{{Player1, 0, 0}, {Player2, 0, 1}}, BookMove{1, 1, 1}},
+		{[]Move{{Player1, last, last}, {Player2, last, last - 1}}, BookMove{last - 1, last - 1, 1}},
+		{[]Move{{Player1, 0, 0}, {Player2, 1, 0}}, BookMove{1, 1, 1}},
+		{[]Move{{Player1, 0, last}, {Player2, 1, last}}, BookMove{1, last - 1, 1}},
+	} {
+		got := book.Moves(gameWith(t, tt.moves...).board)
+		if len(got) != 1 || got[0] != tt.want {
+			t.Errorf("Moves(%v) = %v, want [%v]", tt.moves, got, tt.want)
+		}
+	}
+}
+
+func TestOpeningBookPick(t *testing.T) {
+	book, err := LoadOpeningBook(strings.NewReader("h8 i9 h9\nh8 h10 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := NewBoard(BoardSize)
+	if x, y, ok := book.Pick(empty); !ok || x != 7 || y != 7 {
+		t.Errorf("Pick(empty) = %d, %d, %v; want 7, 7, true", x, y, ok)
+	}
+	// h10 的权重为 0, 不会被选中; 天元旁斜向的点互相等价
+	for i := 0; i < 20; i++ {
+		if x, y, ok := book.Pick(gameWith(t, Move{Player1, 7, 7}).board); !ok || abs(x-7) != 1 || abs(y-7) != 1 {
+			t.Fatalf("Pick(h8) = %d, %d, %v; want a diagonal neighbour like i9", x, y, ok)
+		}
+	}
+	if _, _, ok := book.Pick(gameWith(t, Move{Player1, 0, 0}).board); ok {
+		t.Error("Pick on a position outside the book succeeded")
+	}
+	if _, _, ok := (*OpeningBook)(nil).Pick(empty); ok {
+		t.Error("Pick on a nil book succeeded")
+	}
+	onlyZero, _ := LoadOpeningBook(strings.NewReader("h8 0\n"))
+	if _, _, ok := onlyZero.Pick(empty); ok {
+		t.Error("Pick with only zero weights succeeded")
+	}
+
+	// 走出的局面与库中的 h8 i9 h9 对称
+	want := gameWith(t, Move{Player1, 7, 7}, Move{Player2, 6, 8}, Move{Player1, 6, 7})
+	got := book.RandomLine(10)
+	if len(got) != 3 {
+		t.Fatalf("RandomLine(10) = %v, want 3 moves (the book ends there)", got)
+	}
+	wantBits, gotBits := BitboardFrom(want.board), BitboardFrom(gameWith(t, got...).board)
+	wantKey, _ := wantBits.Canonical()
+	if gotKey, _ := gotBits.Canonical(); gotKey != wantKey {
+		t.Errorf("RandomLine(10) = %v, not a symmetric image of h8 i9 h9", got)
+	}
+	if got := book.RandomLine(2); len(got) != 2 {
+		t.Errorf("RandomLine(2) = %v, want 2 moves", got)
+	}
+}
+
+func TestBookStats(t *testing.T) {
+	h8, i9, g7, j10 := Move{Player1, 7, 7}, Move{Player2, 6, 8}, Move{Player2, 8, 6}, Move{Player2, 5, 9}
+	records := []GameRecord{
+		{Winner: Player1, Moves: []Move{h8, i9, {Player1, 7, 8}}},
+		{Winner: Player2, Moves: []Move{h8, g7}},
+		{Winner: Player1, Moves: []Move{h8, j10}},
+		{Moves: []Move{h8, i9}}, // 没有结果
+		{Winner: Player2, Moves: []Move{h8, i9}, Handicap: Handicap{Player: Player2, Stones: 1}},
+	}
+	list, used := bookStats(records, 2, 1)
+	if used != 3 {
+		t.Errorf("used %d games, want 3", used)
+	}
+	// h8: 3 局 +2 -1; i9 和 g7 合并: 2 局 +1 -1; j10 没有得分, 不列出; 第三手超出 plies
+	if len(list) != 2 {
+		t.Fatalf("bookStats listed %d moves, want 2", len(list))
+	}
+	if s := list[0]; !reflect.DeepEqual(s.moves, []Move{h8}) || s.wins != 2 || s.draws != 0 || s.losses != 1 {
+		t.Errorf("first entry = %v +%d =%d -%d, want h8 +2 =0 -1", s.moves, s.wins, s.draws, s.losses)
+	}
+	if s := list[1]; !reflect.DeepEqual(s.moves, []Move{h8, i9}) || s.wins != 1 || s.losses != 1 {
+		t.Errorf("second entry = %v +%d -%d, want h8 i9 +1 -1", s.moves, s.wins, s.losses)
+	}
+	if list, _ := bookStats(records, 2, 3); len(list) != 1 {
+		t.Errorf("bookStats with min-games 3 listed %d moves, want 1", len(list))
+	}
+}
+
+// 引擎参数中同一个开局库文件只读一次, 各引擎共用
+func TestSharedOpeningBook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "book.txt")
+	if err := os.WriteFile(path, []byte("h8 i9\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a, err := sharedOpeningBook(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := sharedOpeningBook(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b || b.Len() != 2 {
+		t.Errorf("second load returned a different book (%d positions)", b.Len())
+	}
+}
+
+// 开局库只在引擎落子时使用, Search (供 /hint 和复盘) 总是搜索
+func TestOpeningBookOnlyForEngineMoves(t *testing.T) {
+	book, err := LoadOpeningBook(strings.NewReader("h8 a1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ai := NewAI()
+	ai.Depth, ai.Book = 1, book
+	moves := []Move{{Player1, 7, 7}}
+	isCorner := func(m Move) bool { return (m.X == 0 || m.X == BoardSize-1) && (m.Y == 0 || m.Y == BoardSize-1) }
+
+	m, err := (&builtinEngine{ai: ai}).NextMove(moves, Player2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isCorner(m) {
+		t.Errorf("engine move %v, want the book's corner move", m)
+	}
+	if res := ai.Search(gameWith(t, moves...).board, Player2); isCorner(res.Move) {
+		t.Errorf("Search played the book move %v", res.Move)
+	}
+}
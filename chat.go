@@ -0,0 +1,211 @@
+// chat.go
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	maxChatHistory = 1000 // 保留的聊天记录条数, 全屏界面可以回滚查看
+	plainChatLines = 20   // 逐行界面默认显示的条数 (/history 显示全部)
+	emotePrefix    = "/me "
+)
+
+// 聊天记录的种类
+type ChatKind int
+
+const (
+	ChatSay    ChatKind = iota // 普通聊天
+	ChatEmote                  // /me 动作
+	ChatSystem                 // 本地的系统消息 (加入对局, 对局结束, 帮助等), 不写入记录
+)
+
+// 一条聊天记录
+type ChatEntry struct {
+	Time    time.Time
+	Kind    ChatKind
+	Player  int    // 发送者的玩家编号 (系统消息为 0)
+	Sender  string // 发送者的用户名
+	Self    bool   // 本地玩家发送的 (显示为 "你")
+	Text    string
+	MoveNum int // 发送时已经下了几手, 用于在记录中定位
+}
+
+// 聊天内容以 "/me " 开头的是动作; 在网络上原样传输, 浏览器客户端也能识别
+func parseChatContent(content string) (ChatKind, string) {
+	if text, ok := strings.CutPrefix(content, emotePrefix); ok {
+		return ChatEmote, text
+	}
+	return ChatSay, content
+}
+
+// 显示用的一行文本, 带发送时间
+func (e ChatEntry) String() string {
+	ts := e.Time.Format("15:04")
+	sender := e.Sender
+	if e.Self {
+		sender = T("chat.you", e.Player)
+	}
+	switch e.Kind {
+	case ChatEmote:
+		return T("chat.emote", ts, sender, e.Text)
+	case ChatSystem:
+		return T("chat.system", ts, e.Text)
+	}
+	return T("chat.line", ts, sender, e.Text)
+}
+
+// 写入对局记录的一行 (PGN 的 ";" 注释), 使用真实用户名和完整时间
+func (e ChatEntry) RecordLine() string {
+	ts := e.Time.Format("15:04:05")
+	if e.Kind == ChatEmote {
+		return fmt.Sprintf("; [%s] move %d * %s %s", ts, e.MoveNum, e.Sender, e.Text)
+	}
+	return fmt.Sprintf("; [%s] move %d <%s> %s", ts, e.MoveNum, e.Sender, e.Text)
+}
+
+func (gs *GameState) appendChat(e ChatEntry) {
+	gs.chatMu.Lock()
+	defer gs.chatMu.Unlock()
+	gs.chatHistory = append(gs.chatHistory, e)
+	if len(gs.chatHistory) > maxChatHistory {
+		gs.chatHistory = gs.chatHistory[len(gs.chatHistory)-maxChatHistory:]
+	}
+}
+
+// 添加一条玩家的聊天消息 (不能在持有 gs.mu 时调用)
+func (gs *GameState) AddChatMessage(player int, sender, content string, self bool) {
+	gs.mu.Lock()
+	moveNum := len(gs.moves)
+	gs.mu.Unlock()
+	kind, text := parseChatContent(content)
+	gs.appendChat(ChatEntry{
+		Time: time.Now(), Kind: kind, Player: player, Sender: sender, Self: self, Text: text, MoveNum: moveNum,
+	})
+	eventKind := "say"
+	if kind == ChatEmote {
+		eventKind = "emote"
+	}
+	gs.events.Emit(Event{Type: EventChat, Player: player, User: sender, Kind: eventKind, Text: text, MoveNum: moveNum})
+}
+
+// 添加系统消息 (加入对局, 对局结束等), 与聊天消息一起显示
+func (gs *GameState) AddSystemMessage(text string) {
+	gs.appendChat(ChatEntry{Time: time.Now(), Kind: ChatSystem, Text: text})
+}
+
+// 聊天记录的副本
+func (gs *GameState) ChatEntries() []ChatEntry {
+	gs.chatMu.Lock()
+	defer gs.chatMu.Unlock()
+	return append([]ChatEntry(nil), gs.chatHistory...)
+}
+
+// 显示聊天记录: 默认只显示最近的 plainChatLines 条, /history 之后显示一次全部
+func (gs *GameState) DisplayChat() {
+	gs.chatMu.Lock()
+	entries := gs.chatHistory
+	if !gs.showAllChat && len(entries) > plainChatLines {
+		entries = entries[len(entries)-plainChatLines:]
+	}
+	gs.showAllChat = false
+	entries = append([]ChatEntry(nil), entries...)
+	gs.chatMu.Unlock()
+
+	fmt.Println(T("chat.header"))
+	if len(entries) == 0 {
+		fmt.Println(T("chat.empty"))
+	}
+	for _, e := range entries {
+		fmt.Println(e)
+	}
+	fmt.Println(T("chat.footer"))
+}
+
+// --- 斜杠命令 ---
+
+// 处理以 "/" 开头的输入; 聊天类命令不受回合限制
+func (gs *GameState) handleCommand(input string) {
+	cmd, arg, _ := strings.Cut(input, " ")
+	arg = strings.TrimSpace(arg)
+	switch strings.ToLower(cmd) {
+	case "/c", "/say":
+		if arg != "" {
+			gs.sendChat(arg)
+		}
+	case "/me":
+		if arg != "" {
+			gs.sendChat(emotePrefix + arg)
+		} else {
+			gs.ShowNotice(T("notice.usage_me"))
+		}
+	case "/mute":
+		gs.setMuted(arg, true)
+	case "/unmute":
+		gs.setMuted(arg, false)
+	case "/history":
+		if gs.tui != nil {
+			gs.tui.ScrollChat(maxChatHistory) // 滚到最早的消息
+		} else {
+			gs.chatMu.Lock()
+			gs.showAllChat = true
+			gs.chatMu.Unlock()
+		}
+		gs.SetNeedsRedraw()
+	case "/hint":
+		gs.showHint()
+	case "/review":
+		gs.handleReview(arg)
+	case "/quit":
+		gs.Quit()
+	case "/help", "/?":
+		gs.showHelp()
+	default:
+		gs.ShowNotice(T("notice.unknown_command", cmd))
+	}
+}
+
+// 发送聊天消息并记录到本地
+func (gs *GameState) sendChat(content string) {
+	content = sanitizeText(content)
+	if err := checkChatLength(content); err != nil { // 服务器也会检查, 这里提前提示
+		gs.ShowNotice(T("notice.chat_too_long", maxChatLength))
+		return
+	}
+	gs.mu.Lock()
+	myPlayerID := gs.playerID
+	gs.mu.Unlock()
+	sender := gs.userName
+	if sender == "" {
+		sender = T("player.name", myPlayerID)
+	}
+	// 同步发送: 每条消息各开一个 goroutine 会打乱连续几条聊天的顺序
+	gs.SendMessage(Message{Type: MsgTypeChat, Player: myPlayerID, Content: content})
+	gs.AddChatMessage(myPlayerID, sender, content, true)
+	gs.SetNeedsRedraw() // 需要重绘聊天区
+}
+
+// 把命令列表作为系统消息显示在聊天区
+func (gs *GameState) showHelp() {
+	lines := []string{
+		T("help.header"),
+		T("help.move", gs.notation.Format(BoardSize/2, BoardSize/2)),
+		T("help.say"),
+		T("help.me"),
+		T("help.mute"),
+		T("help.history"),
+		T("help.hint"),
+		T("help.review"),
+		T("help.quit"),
+		T("help.help"),
+	}
+	if gs.tui != nil {
+		lines = append(lines, T("help.scroll"))
+	}
+	for _, line := range lines {
+		gs.AddSystemMessage(line)
+	}
+	gs.SetNeedsRedraw()
+}
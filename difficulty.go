@@ -0,0 +1,115 @@
+// difficulty.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 降低难度的两种方式: 给人让子 (Handicap), 或者让 AI 按目标等级分减弱 (strengthLevels)
+
+// 让子: 开局前给受让方摆好的棋子, 以及开局时额外连走的手数
+type Handicap struct {
+	Player int // 受让的一方
+	Stones int // 预先摆好的棋子数, 最多 len(handicapPoints)
+	Moves  int // 额外的落子次数, 最多 maxHandicapMoves
+}
+
+const maxHandicapMoves = 3
+
+// 预先摆放的位置 (行, 列): 天元和周围呈风车形的四个点, 任意三子不在一条线上
+var handicapPoints = [][2]int{
+	{BoardSize / 2, BoardSize / 2},
+	{BoardSize/2 - 2, BoardSize/2 + 1},
+	{BoardSize/2 + 1, BoardSize/2 + 2},
+	{BoardSize/2 + 2, BoardSize/2 - 1},
+	{BoardSize/2 - 1, BoardSize/2 - 2},
+}
+
+// 解析 "stones=2,moves=1" (对局记录中还有 player=N); 没有写的项为 0
+func ParseHandicap(spec string) (Handicap, error) {
+	var h Handicap
+	for _, kv := range strings.Split(spec, ",") {
+		if strings.TrimSpace(kv) == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(kv, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return h, fmt.Errorf("invalid handicap %q", kv)
+		}
+		switch strings.TrimSpace(key) {
+		case "player":
+			h.Player = n
+		case "stones":
+			h.Stones = n
+		case "moves":
+			h.Moves = n
+		default:
+			return h, fmt.Errorf("unknown handicap %q (available: stones, moves)", key)
+		}
+	}
+	return h, h.validate()
+}
+
+// 检查各项的范围; Player 为 0 表示还没有指定受让方
+func (h Handicap) validate() error {
+	switch {
+	case h.Stones < 0 || h.Stones > len(handicapPoints):
+		return fmt.Errorf("at most %d handicap stones", len(handicapPoints))
+	case h.Moves < 0 || h.Moves > maxHandicapMoves:
+		return fmt.Errorf("at most %d extra moves", maxHandicapMoves)
+	case h.Player != 0 && h.Player != Player1 && h.Player != Player2:
+		return errors.New("handicap player must be 1 or 2")
+	}
+	return nil
+}
+
+// 没有让子时为 ""
+func (h Handicap) String() string {
+	if h.Stones == 0 && h.Moves == 0 {
+		return ""
+	}
+	return fmt.Sprintf("player=%d,stones=%d,moves=%d", h.Player, h.Stones, h.Moves)
+}
+
+// --- 目标等级分 ---
+
+// 内置 AI 的各档设置及其等级分. 等级分由 tournament 子命令的循环赛实测 (单核, 每手 1 秒),
+// 以最弱一档为 1000, 只表示档位之间的相对差距; 方法和结果见 calibration/strength-results.txt,
+// 改动搜索或评估后需要重新测量
+type strengthLevel struct {
+	elo     int
+	depth   int
+	width   int
+	vcf     int     // 根节点 VCF 检查的深度, 0 表示不检查
+	blunder float64 // 随手的概率
+}
+
+var strengthLevels = []strengthLevel{
+	{1000, 1, 4, 0, 0.3},
+	{1200, 2, 8, 0, 0.2},
+	{1430, 2, 8, 4, 0},
+	{1520, 3, 10, 6, 0},
+	{1600, aiDefaultDepth, aiDefaultWidth, aiRootVCFDepth, 0}, // 默认设置
+}
+
+// 按目标等级分选择最接近的一档
+func (ai *AI) SetStrength(elo int) {
+	best := strengthLevels[0]
+	for _, l := range strengthLevels {
+		if abs(l.elo-elo) < abs(best.elo-elo) {
+			best = l
+		}
+	}
+	ai.Depth, ai.Width, ai.VCFDepth, ai.Blunder = best.depth, best.width, best.vcf, best.blunder
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
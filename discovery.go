@@ -0,0 +1,199 @@
+// discovery.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	discoveryPort     = 48615           // 局域网广播端口
+	discoveryMagic    = "tictactoe/1"   // 区分其他程序的广播
+	announceInterval  = 2 * time.Second // 服务器广播间隔
+	defaultRulesLabel = "Freestyle gomoku, 15x15, five in a row"
+	announceNameWidth = 32 // 广播中主机名和用户名的最大显示宽度
+	announceRuleWidth = 80 // 规则说明的最大显示宽度
+)
+
+// 服务器在局域网内广播的对局信息
+type Announcement struct {
+	Magic    string `json:"magic"`
+	Host     string `json:"host"`                // 主机名
+	User     string `json:"user"`                // 等待中的玩家
+	Rules    string `json:"rules"`               // 规则说明
+	Port     int    `json:"port,omitempty"`      // TCP (JSON) 端口
+	GRPCPort int    `json:"grpc_port,omitempty"` // gRPC 端口
+	WSPort   int    `json:"ws_port,omitempty"`   // 浏览器客户端端口
+	TLS      bool   `json:"tls,omitempty"`       // 是否需要 TLS
+	Auth     bool   `json:"auth,omitempty"`      // 是否需要密码或令牌
+	Waiting  bool   `json:"waiting"`             // 是否还在等待对手
+
+	addr net.IP // 发送方地址 (接收时填写)
+}
+
+// 从 ":8080" 或 "host:8080" 中取出端口号, 失败时返回 0
+func portOf(addr string) int {
+	_, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0
+	}
+	port, _ := strconv.Atoi(portStr)
+	return port
+}
+
+// Goroutine: 定期广播对局信息, 直到 stop 关闭 (之后再广播一次 Waiting=false)
+func announceGame(info Announcement, stop <-chan struct{}) {
+	conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: net.IPv4bcast, Port: discoveryPort})
+	if err != nil {
+		slog.Warn("LAN announcement disabled", "err", err)
+		return
+	}
+	defer conn.Close()
+
+	info.Magic = discoveryMagic
+	if info.Host == "" {
+		info.Host, _ = os.Hostname()
+	}
+	send := func() {
+		data, _ := json.Marshal(info)
+		if _, err := conn.Write(data); err != nil {
+			slog.Warn("LAN announcement failed", "err", err)
+		}
+	}
+
+	ticker := time.NewTicker(announceInterval)
+	defer ticker.Stop()
+	info.Waiting = true
+	send()
+	for {
+		select {
+		case <-ticker.C:
+			send()
+		case <-stop:
+			info.Waiting = false
+			send()
+			return
+		}
+	}
+}
+
+// 监听一段时间内的广播, 返回仍在等待对手的对局 (按主机名排序)
+func discoverGames(wait time.Duration) ([]Announcement, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: discoveryPort})
+	if err != nil {
+		return nil, fmt.Errorf("listen for announcements: %w", err)
+	}
+	defer conn.Close()
+
+	found := make(map[string]Announcement) // 以 ip:port 去重, 保留最新的广播
+	buf := make([]byte, 2048)
+	deadline := time.Now().Add(wait)
+	conn.SetReadDeadline(deadline)
+	for time.Now().Before(deadline) {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				break
+			}
+			return nil, err
+		}
+		if a, ok := parseAnnouncement(buf[:n], from.IP); ok {
+			found[a.key()] = a
+		}
+	}
+	return waitingGames(found), nil
+}
+
+// 解析收到的广播; 不是本程序的广播时 ok 为 false. 广播没有认证, 局域网里谁都能发,
+// 要显示的文字字段去掉控制字符 (终端转义序列, 换行) 并限制长度
+func parseAnnouncement(data []byte, from net.IP) (a Announcement, ok bool) {
+	if json.Unmarshal(data, &a) != nil || a.Magic != discoveryMagic {
+		return a, false
+	}
+	a.Host = truncateWidth(strings.TrimSpace(sanitizeText(a.Host)), announceNameWidth)
+	a.User = truncateWidth(strings.TrimSpace(sanitizeText(a.User)), announceNameWidth)
+	a.Rules = truncateWidth(strings.TrimSpace(sanitizeText(a.Rules)), announceRuleWidth)
+	a.addr = from
+	return a, true
+}
+
+// 去重用的键: 同一台主机上的同一个服务器只保留最新的广播
+func (a Announcement) key() string {
+	return fmt.Sprintf("%s:%d:%d", a.addr, a.Port, a.GRPCPort)
+}
+
+// 仍在等待对手并且有可连接端口的对局 (按主机名和地址排序)
+func waitingGames(found map[string]Announcement) []Announcement {
+	games := make([]Announcement, 0, len(found))
+	for _, a := range found {
+		if a.Waiting && (a.Port != 0 || a.GRPCPort != 0) {
+			games = append(games, a)
+		}
+	}
+	sort.Slice(games, func(i, j int) bool {
+		if games[i].Host != games[j].Host {
+			return games[i].Host < games[j].Host
+		}
+		if ai, aj := games[i].addr.String(), games[j].addr.String(); ai != aj {
+			return ai < aj
+		}
+		return games[i].key() < games[j].key()
+	})
+	return games
+}
+
+// --connect 使用的地址; 只提供 gRPC 的服务器返回 grpc:// 地址
+func (a Announcement) ConnectAddr() string {
+	if a.Port != 0 {
+		return net.JoinHostPort(a.addr.String(), strconv.Itoa(a.Port))
+	}
+	return "grpc://" + net.JoinHostPort(a.addr.String(), strconv.Itoa(a.GRPCPort))
+}
+
+// 列出发现的对局并让用户选择, 返回要连接的地址
+func chooseDiscoveredGame(wait time.Duration) (string, error) {
+	fmt.Println(T("discover.looking", wait))
+	games, err := discoverGames(wait)
+	if err != nil {
+		return "", err
+	}
+	if len(games) == 0 {
+		return "", errors.New(T("discover.none"))
+	}
+
+	fmt.Println(Tn("discover.found", len(games)))
+	for i, a := range games {
+		var flags []string
+		if a.TLS {
+			flags = append(flags, "TLS")
+		}
+		if a.Auth {
+			flags = append(flags, T("discover.login_required"))
+		}
+		extra := ""
+		if len(flags) > 0 {
+			extra = " [" + strings.Join(flags, ", ") + "]"
+		}
+		fmt.Println(T("discover.entry", i+1, a.User, a.Host, a.ConnectAddr(), a.Rules, extra))
+	}
+	for {
+		fmt.Print(T("discover.choose", len(games)))
+		line, err := stdinReader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(line))
+		if err == nil && n >= 1 && n <= len(games) {
+			return games[n-1].ConnectAddr(), nil
+		}
+		fmt.Println(T("discover.invalid_choice"))
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAnnouncement(t *testing.T) {
+	from := net.IPv4(192, 168, 1, 20)
+	a, ok := parseAnnouncement([]byte(`{"magic":"tictactoe/1","host":"desk\u001b]0;pwned\u0007","user":"alice\nFake entry 2. mallory","rules":"`+strings.Repeat("r", 200)+`","port":8080,"waiting":true}`), from)
+	if !ok {
+		t.Fatal("parseAnnouncement rejected a valid announcement")
+	}
+	if a.Host != "desk]0;pwned" {
+		t.Errorf("Host = %q, want the escape sequence stripped", a.Host)
+	}
+	if a.User != "alice Fake entry 2. mallory" {
+		t.Errorf("User = %q, want the newline replaced", a.User)
+	}
+	if len(a.Rules) != announceRuleWidth {
+		t.Errorf("len(Rules) = %d, want %d", len(a.Rules), announceRuleWidth)
+	}
+	if !a.addr.Equal(from) || a.ConnectAddr() != "192.168.1.20:8080" {
+		t.Errorf("ConnectAddr() = %q", a.ConnectAddr())
+	}
+
+	for _, bad := range []string{
+		`{"magic":"other/1","port":8080,"waiting":true}`,
+		`not json`,
+		`{"port":8080}`,
+	} {
+		if _, ok := parseAnnouncement([]byte(bad), from); ok {
+			t.Errorf("parseAnnouncement(%s) accepted", bad)
+		}
+	}
+}
+
+// 同一服务器的广播只保留最新的一条; 不再等待或没有端口的不列出
+func TestWaitingGames(t *testing.T) {
+	found := make(map[string]Announcement)
+	add := func(ip string, data string) {
+		if a, ok := parseAnnouncement([]byte(data), net.ParseIP(ip)); ok {
+			found[a.key()] = a
+		}
+	}
+	add("10.0.0.2", `{"magic":"tictactoe/1","host":"b","user":"old","port":8080,"waiting":true}`)
+	add("10.0.0.2", `{"magic":"tictactoe/1","host":"b","user":"bob","port":8080,"waiting":true}`)
+	add("10.0.0.2", `{"magic":"tictactoe/1","host":"b","user":"bob","grpc_port":9090,"waiting":true}`)
+	add("10.0.0.1", `{"magic":"tictactoe/1","host":"a","user":"alice","port":8080,"waiting":true}`)
+	add("10.0.0.3", `{"magic":"tictactoe/1","host":"c","user":"carol","port":8080,"waiting":true}`)
+	add("10.0.0.3", `{"magic":"tictactoe/1","host":"c","user":"carol","port":8080,"waiting":false}`)
+	add("10.0.0.4", `{"magic":"tictactoe/1","host":"d","user":"dave","waiting":true}`)
+
+	var got []string
+	for _, a := range waitingGames(found) {
+		got = append(got, a.User+"@"+a.ConnectAddr())
+	}
+	want := []string{"alice@10.0.0.1:8080", "bob@grpc://10.0.0.2:9090", "bob@10.0.0.2:8080"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("waitingGames = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,242 @@
+// engine.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 对局引擎: 内置 AI 或外部的 Piskvork 引擎
+type Engine interface {
+	Name() string
+	// player 的下一手; moves 是到目前为止的全部落子
+	NextMove(moves []Move, player int) (Move, error)
+	Close() error
+}
+
+const builtinEngineName = "builtin"
+
+// 按 --vs / --engine 的取值创建引擎: "builtin" 是内置 AI, 可以带参数
+// (如 "builtin:depth=3,width=8,book=book.txt" 或 "builtin:strength=1400"), 其他按 Piskvork 引擎的命令行启动.
+// threads 是内置 AI 默认的搜索线程数, 参数中的 threads 优先
+func NewEngine(spec string, timeout time.Duration, threads int) (Engine, error) {
+	spec = strings.TrimSpace(spec)
+	if name, params, _ := strings.Cut(spec, ":"); name == builtinEngineName {
+		ai := NewAI()
+		ai.TimeLimit, ai.Threads = timeout, threads
+		if err := ai.configure(params); err != nil {
+			return nil, err
+		}
+		return &builtinEngine{ai: ai}, nil
+	}
+	e, err := StartPiskvork(spec, timeout)
+	if err != nil {
+		return nil, err // 不能返回包着 nil 指针的接口
+	}
+	return e, nil
+}
+
+// 解析内置 AI 的参数 "key=value,..."
+func (ai *AI) configure(params string) error {
+	for _, kv := range strings.Split(params, ",") {
+		if strings.TrimSpace(kv) == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(kv, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		var err error
+		switch key {
+		case "depth":
+			ai.Depth, err = strconv.Atoi(value)
+		case "width":
+			ai.Width, err = strconv.Atoi(value)
+		case "time":
+			ai.TimeLimit, err = time.ParseDuration(value)
+		case "threads":
+			ai.Threads, err = strconv.Atoi(value)
+		case "vcf":
+			ai.VCFDepth, err = strconv.Atoi(value)
+		case "blunder":
+			ai.Blunder, err = strconv.ParseFloat(value, 64)
+		case "strength":
+			var elo int
+			elo, err = strconv.Atoi(value)
+			ai.SetStrength(elo) // 之后的参数可以再调整单项
+		case "book":
+			ai.Book, err = sharedOpeningBook(value)
+		default:
+			return fmt.Errorf("unknown builtin engine option %q (available: depth, width, time, threads, vcf, blunder, strength, book)", key)
+		}
+		if err != nil {
+			return fmt.Errorf("builtin engine option %s: %v", key, err)
+		}
+	}
+	if ai.Depth < 1 || ai.Width < 1 || ai.Threads < 1 {
+		return errors.New("builtin engine depth, width and threads must be at least 1")
+	}
+	if ai.VCFDepth < 0 || ai.Blunder < 0 || ai.Blunder > 1 {
+		return errors.New("builtin engine vcf must not be negative and blunder must be between 0 and 1")
+	}
+	return nil
+}
+
+// 内置 AI 作为引擎
+type builtinEngine struct {
+	ai *AI
+}
+
+func (e *builtinEngine) Name() string { return "builtin AI" }
+func (e *builtinEngine) Close() error { return nil }
+
+func (e *builtinEngine) NextMove(moves []Move, player int) (Move, error) {
+	board := NewBoard(BoardSize)
+	for _, m := range moves {
+		board[m.X][m.Y] = m.Player
+	}
+	// 局面在开局库中时直接按权重选一手; 搜索本身不查库, 所以 /hint 和复盘总是给出真实的评估
+	if x, y, ok := e.ai.Book.Pick(board); ok {
+		return Move{Player: player, X: x, Y: y}, nil
+	}
+	res := e.ai.Search(board, player)
+	if res.Move.Player == 0 {
+		return Move{}, errors.New("no legal move")
+	}
+	return res.Move, nil
+}
+
+// --- 本地对局: 引擎作为连接的对端 ---
+
+// 把引擎包装成 Transport, 供本机对局 (--vs) 使用: 服务器照常发送分配, 落子和状态,
+// 引擎在轮到自己时算出一手, 作为对方的 move 消息返回
+type engineTransport struct {
+	engine Engine
+	mu     sync.Mutex
+	game   *Game // 引擎这一侧看到的棋局
+	seat   int   // 引擎执哪一方, 收到分配后确定
+	out    chan Message
+	done   chan struct{}
+	once   sync.Once
+}
+
+// h 是对局的让子, 引擎这一侧的棋局要与服务器一致
+func newEngineTransport(engine Engine, h Handicap) (*engineTransport, error) {
+	t := &engineTransport{
+		engine: engine,
+		game:   NewGame(),
+		out:    make(chan Message, 4),
+		done:   make(chan struct{}),
+	}
+	if err := t.game.ApplyHandicap(h); err != nil {
+		return nil, err
+	}
+	t.out <- Message{Type: MsgTypeLogin, User: engine.Name()} // 服务器先读登录消息
+	return t, nil
+}
+
+func (t *engineTransport) Send(msg Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch msg.Type {
+	case MsgTypeAssign:
+		t.seat = msg.Player
+	case MsgTypeMove:
+		if err := t.game.Play(msg.Player, msg.X, msg.Y); err != nil {
+			slog.Warn("Engine transport rejected move", "x", msg.X, "y", msg.Y, "err", err)
+		}
+	case MsgTypeError:
+		slog.Warn("Error sent to engine", "content", msg.Content)
+	default:
+		return nil
+	}
+	if t.seat != 0 && t.game.currentPlayer == t.seat && !t.game.gameOver {
+		go t.think(t.game.Moves())
+	}
+	return nil
+}
+
+// 在后台算出引擎的一手并交给 Receive
+func (t *engineTransport) think(moves []Move) {
+	m, err := t.engine.NextMove(moves, t.seat)
+	if err != nil {
+		slog.Error("Engine failed", "engine", t.engine.Name(), "err", err)
+		t.Close()
+		return
+	}
+	t.mu.Lock()
+	err = t.game.Play(t.seat, m.X, m.Y)
+	t.mu.Unlock()
+	if err != nil {
+		slog.Error("Engine played an illegal move", "engine", t.engine.Name(), "x", m.X, "y", m.Y, "err", err)
+		t.Close()
+		return
+	}
+	select {
+	case t.out <- Message{Type: MsgTypeMove, Player: t.seat, X: m.X, Y: m.Y}:
+	case <-t.done:
+	}
+}
+
+func (t *engineTransport) Receive(msg *Message) error {
+	select {
+	case m := <-t.out:
+		*msg = m
+		return nil
+	case <-t.done:
+		return io.EOF
+	}
+}
+
+func (t *engineTransport) SetReadDeadline(time.Time) error { return nil }
+func (t *engineTransport) RemoteAddr() net.Addr            { return engineAddr(t.engine.Name()) }
+
+func (t *engineTransport) Close() error {
+	t.once.Do(func() {
+		close(t.done)
+		t.engine.Close()
+	})
+	return nil
+}
+
+// 引擎 "连接" 的地址, 只用于日志和提示
+type engineAddr string
+
+func (a engineAddr) Network() string { return "engine" }
+func (a engineAddr) String() string  { return string(a) }
+
+// --- 由引擎代替本地玩家落子 (--engine) ---
+
+// 轮到本地玩家时让引擎落子; 在主循环中调用, 引擎在后台计算, 结果按输入处理
+func (gs *GameState) maybeStartEngine() {
+	if gs.engine == nil {
+		return
+	}
+	gs.mu.Lock()
+	due := gs.playerID != 0 && gs.currentPlayer == gs.playerID && !gs.gameOver && gs.engineMoveNum != len(gs.moves)
+	moves, player := gs.Moves(), gs.playerID
+	if due {
+		gs.engineMoveNum = len(gs.moves) // 每个局面只请求一次
+	}
+	gs.mu.Unlock()
+	if !due {
+		return
+	}
+	go func() {
+		m, err := gs.engine.NextMove(moves, player)
+		if err != nil {
+			gs.ShowNotice(T("notice.engine_failed", gs.engine.Name(), err))
+			gs.events.Emit(errorEvent("engine %s: %v", gs.engine.Name(), err))
+			return
+		}
+		select {
+		case gs.inputChan <- gs.notation.Format(m.X, m.Y):
+		case <-gs.quitChan:
+		}
+	}()
+}
@@ -0,0 +1,167 @@
+// events.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 事件流 (--events): 对局中发生的事按行写成 JSON (NDJSON), 供直播叠加层, 日志等外部工具读取.
+// 与界面和日志的文字无关, 字段和取值保持稳定, 不随 --lang 和 --notation 变化
+//
+//	{"time":"2026-10-18T20:15:03.52+08:00","type":"move","player":1,"move":"h8","coord":[7,7],"move_num":1,"hash":"..."}
+
+// 事件类型
+const (
+	EventGameStarted = "game_started" // 双方就位: player 为本地玩家, user/opponent 为双方名字
+	EventMove        = "move"         // 任一方落子
+	EventChat        = "chat"         // 玩家的聊天或动作 (kind 为 say 或 emote), 不含本地的系统消息
+	EventState       = "state"        // 服务器发出或客户端收到的权威状态: turn, winner, hash
+	EventGameOver    = "game_over"    // 对局结束: winner 为 0 时是中断 (断线或退出)
+	EventError       = "error"        // 错误: 远端的错误消息, 非法落子, 发送失败, 棋盘不同步等
+)
+
+type Event struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"`
+	Player   int       `json:"player,omitempty"`   // 相关的玩家编号
+	User     string    `json:"user,omitempty"`     // 本地用户名 (game_started), 或聊天的发送者
+	Opponent string    `json:"opponent,omitempty"` // 对手的名字 (game_started)
+	Move     string    `json:"move,omitempty"`     // 代数记法的坐标, 如 h8
+	Coord    []int     `json:"coord,omitempty"`    // [行, 列] 下标, 即 board[x][y]
+	MoveNum  int       `json:"move_num,omitempty"` // 落子后的总手数, 含让子的棋子
+	Turn     int       `json:"turn,omitempty"`     // 轮到谁; 0 表示对局已结束
+	Winner   int       `json:"winner,omitempty"`   // 1, 2, 3 (平局)
+	Hash     string    `json:"hash,omitempty"`     // 局面哈希, 与状态消息中的相同
+	Kind     string    `json:"kind,omitempty"`     // 聊天的类别: say 或 emote
+	Text     string    `json:"text,omitempty"`     // 聊天内容或错误信息
+	Handicap string    `json:"handicap,omitempty"` // 让子, 格式同 --handicap
+	Role     string    `json:"role,omitempty"`     // 本地的角色: server 或 client (game_started)
+	Game     string    `json:"game,omitempty"`     // 对局编号, 与日志中的相同 (game_started)
+}
+
+const eventQueueSize = 256 // 写入跟不上时最多积压的事件数, 超出后丢弃, 不拖慢对局
+
+// 事件的输出; 为 nil 时 Emit 什么也不做, 调用方不必判断
+type EventLog struct {
+	w       io.WriteCloser
+	queue   chan Event
+	done    chan struct{}
+	mu      sync.Mutex // 保护 closed 和 dropped
+	closed  bool
+	dropped int
+}
+
+// 打开事件流: "unix:/路径" 或 "tcp:主机:端口" 连接到外部工具监听的套接字;
+// 已存在的 Unix 套接字文件也按套接字连接; 其他路径作为文件追加写入
+func OpenEventLog(dest string) (*EventLog, error) {
+	var w io.WriteCloser
+	var err error
+	if network, addr, ok := strings.Cut(dest, ":"); ok && (network == "unix" || network == "tcp") {
+		w, err = net.DialTimeout(network, addr, 5*time.Second)
+	} else if fi, statErr := os.Stat(dest); statErr == nil && fi.Mode()&os.ModeSocket != 0 {
+		w, err = net.DialTimeout("unix", dest, 5*time.Second)
+	} else {
+		w, err = os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open event stream %s: %w", dest, err)
+	}
+	l := &EventLog{w: w, queue: make(chan Event, eventQueueSize), done: make(chan struct{})}
+	go l.writeLoop()
+	return l, nil
+}
+
+// 记下一个事件, 不阻塞; Time 为零时填入当前时间
+func (l *EventLog) Emit(e Event) {
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	select {
+	case l.queue <- e:
+	default:
+		l.dropped++
+	}
+}
+
+// Goroutine: 逐行写出事件; 写入失败 (例如对方关闭了套接字) 后丢弃其余的事件
+func (l *EventLog) writeLoop() {
+	defer close(l.done)
+	enc := json.NewEncoder(l.w) // Encode 在每个值后加换行
+	failed := false
+	for e := range l.queue {
+		if failed {
+			continue
+		}
+		if err := enc.Encode(e); err != nil {
+			slog.Warn("Event stream stopped", "err", err)
+			failed = true
+		}
+	}
+}
+
+// 写完已经排队的事件后关闭
+func (l *EventLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	close(l.queue)
+	dropped := l.dropped
+	l.mu.Unlock()
+	<-l.done
+	if dropped > 0 {
+		slog.Warn("Dropped events because the event stream could not keep up", "count", dropped)
+	}
+	return l.w.Close()
+}
+
+// 落子事件 (需要在外部加锁调用, 在 Play 之后)
+func (gs *GameState) moveEventInternal(player, x, y int) Event {
+	return Event{
+		Type: EventMove, Player: player, Move: NotationAlgebraic.Format(x, y), Coord: []int{x, y},
+		MoveNum: len(gs.moves), Hash: gs.HashText(),
+	}
+}
+
+// 状态事件, 取自状态消息
+func stateEvent(msg Message) Event {
+	return Event{Type: EventState, Turn: msg.Turn, Winner: msg.Winner, Hash: msg.Hash}
+}
+
+// 错误事件
+func errorEvent(format string, args ...any) Event {
+	return Event{Type: EventError, Text: fmt.Sprintf(format, args...)}
+}
+
+// 对局开始的事件 (需要在外部加锁调用)
+func (gs *GameState) startedEventInternal() Event {
+	role := "client"
+	if gs.isServer {
+		role = "server"
+	}
+	return Event{
+		Type: EventGameStarted, Player: gs.playerID, User: gs.userName, Opponent: gs.peerName,
+		Handicap: gs.handicap.String(), Role: role, Hash: gs.HashText(), Game: gs.gameID,
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var eventTime = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+// 每个事件一行 JSON, 字段名和顺序是外部工具依赖的格式
+func TestEventLogFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	l, err := OpenEventLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Emit(Event{Time: eventTime, Type: EventGameStarted, Player: Player1, User: "alice", Opponent: "bob", Role: "server", Game: "3f2a9c1b", Hash: "0000000000000000"})
+	l.Emit(Event{Time: eventTime, Type: EventMove, Player: Player1, Move: "h8", Coord: []int{7, 7}, MoveNum: 1, Hash: "00c0ffee12345678"})
+	l.Emit(Event{Time: eventTime, Type: EventChat, Player: Player2, User: "bob", Kind: "emote", Text: "waves"})
+	l.Emit(stateEvent(Message{Type: MsgTypeState, Turn: Player2, Hash: "00c0ffee12345678"}))
+	l.Emit(Event{Time: eventTime, Type: EventGameOver, Winner: Draw})
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Close 之后的事件被忽略
+	l.Emit(Event{Time: eventTime, Type: EventError, Text: "too late"})
+	if err := l.Close(); err != nil {
+		t.Errorf("second Close = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	want := []string{
+		`{"time":"2026-10-18T12:00:00Z","type":"game_started","player":1,"user":"alice","opponent":"bob","hash":"0000000000000000","role":"server","game":"3f2a9c1b"}`,
+		`{"time":"2026-10-18T12:00:00Z","type":"move","player":1,"move":"h8","coord":[7,7],"move_num":1,"hash":"00c0ffee12345678"}`,
+		`{"time":"2026-10-18T12:00:00Z","type":"chat","player":2,"user":"bob","kind":"emote","text":"waves"}`,
+		"", // state: 时间由 Emit 填入, 单独检查
+		`{"time":"2026-10-18T12:00:00Z","type":"game_over","winner":3}`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("wrote %d lines, want %d:\n%s", len(lines), len(want), data)
+	}
+	for i, w := range want {
+		if w != "" && lines[i] != w {
+			t.Errorf("line %d = %s\nwant %s", i+1, lines[i], w)
+		}
+	}
+	stamp, rest, ok := strings.Cut(strings.TrimPrefix(lines[3], `{"time":"`), `",`)
+	if _, err := time.Parse(time.RFC3339Nano, stamp); !ok || err != nil {
+		t.Errorf("state line %s: time not filled in", lines[3])
+	}
+	if want := `"type":"state","turn":2,"hash":"00c0ffee12345678"}`; rest != want {
+		t.Errorf("state line %s, want ...%s", lines[3], want)
+	}
+
+	var nilLog *EventLog // 没有 --events 时
+	nilLog.Emit(Event{Type: EventMove})
+	if err := nilLog.Close(); err != nil {
+		t.Errorf("nil Close = %v", err)
+	}
+}
+
+// Close 写完排队中的事件再返回
+func TestEventLogCloseFlushes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	l, err := OpenEventLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = eventQueueSize / 2
+	for i := 0; i < n; i++ {
+		l.Emit(Event{Time: eventTime, Type: EventMove, MoveNum: i + 1})
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), "\n"); got != n {
+		t.Errorf("wrote %d events, want %d", got, n)
+	}
+	if !strings.Contains(string(data), fmt.Sprintf(`"move_num":%d}`, n)) {
+		t.Error("the last event is missing")
+	}
+}
+
+// "unix:" 和 "tcp:" 连接到外部工具监听的套接字; 已存在的 Unix 套接字文件不写前缀也可以
+func TestEventLogSockets(t *testing.T) {
+	dir, err := os.MkdirTemp("", "ev") // Unix 套接字的路径有长度限制, 不用较长的 t.TempDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "events.sock")
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	unix, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close()
+
+	for _, tt := range []struct {
+		dest string
+		ln   net.Listener
+	}{
+		{"tcp:" + tcp.Addr().String(), tcp},
+		{"unix:" + sock, unix},
+		{sock, unix},
+	} {
+		lines := make(chan string, 1)
+		go func() {
+			conn, err := tt.ln.Accept()
+			if err != nil {
+				lines <- err.Error()
+				return
+			}
+			defer conn.Close()
+			line, err := bufio.NewReader(conn).ReadString('\n')
+			if err != nil && err != io.EOF {
+				line = err.Error()
+			}
+			lines <- line
+		}()
+		l, err := OpenEventLog(tt.dest)
+		if err != nil {
+			t.Fatalf("OpenEventLog(%q): %v", tt.dest, err)
+		}
+		l.Emit(Event{Time: eventTime, Type: EventGameOver, Winner: Player2})
+		if err := l.Close(); err != nil {
+			t.Errorf("%s: Close = %v", tt.dest, err)
+		}
+		want := `{"time":"2026-10-18T12:00:00Z","type":"game_over","winner":2}` + "\n"
+		if got := <-lines; got != want {
+			t.Errorf("%s: received %q, want %q", tt.dest, got, want)
+		}
+	}
+
+	if _, err := OpenEventLog("tcp:127.0.0.1:1"); err == nil {
+		t.Error("OpenEventLog to a closed port succeeded")
+	}
+}
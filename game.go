@@ -0,0 +1,108 @@
+// game.go
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 平局时 winner 的取值
+const Draw = 3
+
+var (
+	ErrGameOver    = errors.New("game is over")
+	ErrNotYourTurn = errors.New("not your turn")
+	ErrInvalidMove = errors.New("invalid move")
+)
+
+// 一步落子记录
+type Move struct {
+	Player int `json:"player"`
+	X      int `json:"x"` // 行
+	Y      int `json:"y"` // 列
+}
+
+// 一局棋的规则和状态; 终端对局, 浏览器对局和 HTTP API 共用同一套校验
+// 本身不加锁, 由调用方保护
+type Game struct {
+	board         [][]int
+	currentPlayer int
+	winner        int // 0: 进行中, 1: Player1, 2: Player2, 3: 平局
+	gameOver      bool
+	moves         []Move   // 按顺序记录的落子
+	bits          Bitboard // 与 board 同步, 用于局面哈希
+	handicap      Handicap // 开局时的让子
+	extra         [3]int   // 各方剩余的额外落子次数 (让子)
+}
+
+// 新的一局, 玩家1先手
+func NewGame() *Game {
+	return &Game{board: NewBoard(BoardSize), currentPlayer: Player1}
+}
+
+// 尝试落子 (需要在外部加锁调用)
+func (g *Game) placePieceInternal(x, y, player int) bool {
+	if x < 0 || x >= BoardSize || y < 0 || y >= BoardSize || g.board[x][y] != Empty {
+		return false
+	}
+	g.board[x][y] = player
+	g.bits.Place(x, y, player)
+	return true
+}
+
+// 校验并执行一步落子, 之后判定胜负或切换回合 (需要在外部加锁调用)
+func (g *Game) Play(player, x, y int) error {
+	if g.gameOver {
+		return ErrGameOver
+	}
+	if player != g.currentPlayer {
+		return ErrNotYourTurn
+	}
+	if !g.placePieceInternal(x, y, player) {
+		return ErrInvalidMove
+	}
+	g.moves = append(g.moves, Move{Player: player, X: x, Y: y})
+	if checkWinLogic(g.board, player) {
+		g.winner = player
+		g.gameOver = true
+	} else if checkDrawLogic(g.board) {
+		g.winner = Draw
+		g.gameOver = true
+	} else if g.extra[player] > 0 {
+		g.extra[player]-- // 让子: 同一方再走一手
+	} else {
+		g.currentPlayer = 3 - player // 切换回合
+	}
+	return nil
+}
+
+// 开局前让子: 为 h.Player 摆好棋子 (记入落子记录), 并给它额外的落子次数; 只能在空棋盘上调用
+// (需要在外部加锁调用)
+func (g *Game) ApplyHandicap(h Handicap) error {
+	if len(g.moves) > 0 {
+		return errors.New("handicap must be set before the first move")
+	}
+	if err := h.validate(); err != nil {
+		return err
+	}
+	if h.Player == 0 && (h.Stones > 0 || h.Moves > 0) {
+		return errors.New("handicap player must be 1 or 2")
+	}
+	for _, p := range handicapPoints[:h.Stones] {
+		g.placePieceInternal(p[0], p[1], h.Player)
+		g.moves = append(g.moves, Move{Player: h.Player, X: p[0], Y: p[1]})
+	}
+	g.extra[h.Player] = h.Moves
+	g.handicap = h
+	return nil
+}
+
+// 局面的 Zobrist 哈希, 十六进制; 随状态消息发送, 供对方核对棋盘是否一致 (需要在外部加锁调用)
+func (g *Game) HashText() string {
+	return fmt.Sprintf("%016x", g.bits.Hash())
+}
+
+// 落子记录的副本 (需要在外部加锁调用)
+func (g *Game) Moves() []Move {
+	return append([]Move(nil), g.moves...)
+}
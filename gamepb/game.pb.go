@@ -0,0 +1,833 @@
+// game.proto
+//
+// gRPC 版本的对局协议, 与 TCP/WebSocket 上的 JSON Message 一一对应.
+// 生成代码: 在仓库根目录运行 `buf generate` (见 buf.gen.yaml).
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: game.proto
+
+package gamepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// 玩家编号, 取值与 JSON 协议中的整数相同.
+type Player int32
+
+const (
+	Player_PLAYER_UNSPECIFIED Player = 0
+	Player_PLAYER_ONE         Player = 1 // X, 先手
+	Player_PLAYER_TWO         Player = 2 // O
+)
+
+// Enum value maps for Player.
+var (
+	Player_name = map[int32]string{
+		0: "PLAYER_UNSPECIFIED",
+		1: "PLAYER_ONE",
+		2: "PLAYER_TWO",
+	}
+	Player_value = map[string]int32{
+		"PLAYER_UNSPECIFIED": 0,
+		"PLAYER_ONE":         1,
+		"PLAYER_TWO":         2,
+	}
+)
+
+func (x Player) Enum() *Player {
+	p := new(Player)
+	*p = x
+	return p
+}
+
+func (x Player) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Player) Descriptor() protoreflect.EnumDescriptor {
+	return file_game_proto_enumTypes[0].Descriptor()
+}
+
+func (Player) Type() protoreflect.EnumType {
+	return &file_game_proto_enumTypes[0]
+}
+
+func (x Player) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Player.Descriptor instead.
+func (Player) EnumDescriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{0}
+}
+
+// 对局结果, 取值与 JSON 协议中的 winner 相同.
+type Outcome int32
+
+const (
+	Outcome_OUTCOME_IN_PROGRESS     Outcome = 0
+	Outcome_OUTCOME_PLAYER_ONE_WINS Outcome = 1
+	Outcome_OUTCOME_PLAYER_TWO_WINS Outcome = 2
+	Outcome_OUTCOME_DRAW            Outcome = 3
+)
+
+// Enum value maps for Outcome.
+var (
+	Outcome_name = map[int32]string{
+		0: "OUTCOME_IN_PROGRESS",
+		1: "OUTCOME_PLAYER_ONE_WINS",
+		2: "OUTCOME_PLAYER_TWO_WINS",
+		3: "OUTCOME_DRAW",
+	}
+	Outcome_value = map[string]int32{
+		"OUTCOME_IN_PROGRESS":     0,
+		"OUTCOME_PLAYER_ONE_WINS": 1,
+		"OUTCOME_PLAYER_TWO_WINS": 2,
+		"OUTCOME_DRAW":            3,
+	}
+)
+
+func (x Outcome) Enum() *Outcome {
+	p := new(Outcome)
+	*p = x
+	return p
+}
+
+func (x Outcome) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Outcome) Descriptor() protoreflect.EnumDescriptor {
+	return file_game_proto_enumTypes[1].Descriptor()
+}
+
+func (Outcome) Type() protoreflect.EnumType {
+	return &file_game_proto_enumTypes[1]
+}
+
+func (x Outcome) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Outcome.Descriptor instead.
+func (Outcome) EnumDescriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{1}
+}
+
+// 流上的每一条消息恰好携带一种负载.
+type Envelope struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*Envelope_Login
+	//	*Envelope_Assign
+	//	*Envelope_Move
+	//	*Envelope_Chat
+	//	*Envelope_State
+	//	*Envelope_Error
+	//	*Envelope_Notify
+	Payload       isEnvelope_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	mi := &file_game_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Envelope) GetPayload() isEnvelope_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Envelope) GetLogin() *Login {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Login); ok {
+			return x.Login
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetAssign() *Assign {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Assign); ok {
+			return x.Assign
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMove() *Move {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Move); ok {
+			return x.Move
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetChat() *Chat {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Chat); ok {
+			return x.Chat
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetState() *State {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_State); ok {
+			return x.State
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetNotify() *Notify {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Notify); ok {
+			return x.Notify
+		}
+	}
+	return nil
+}
+
+type isEnvelope_Payload interface {
+	isEnvelope_Payload()
+}
+
+type Envelope_Login struct {
+	Login *Login `protobuf:"bytes,1,opt,name=login,proto3,oneof"`
+}
+
+type Envelope_Assign struct {
+	Assign *Assign `protobuf:"bytes,2,opt,name=assign,proto3,oneof"`
+}
+
+type Envelope_Move struct {
+	Move *Move `protobuf:"bytes,3,opt,name=move,proto3,oneof"`
+}
+
+type Envelope_Chat struct {
+	Chat *Chat `protobuf:"bytes,4,opt,name=chat,proto3,oneof"`
+}
+
+type Envelope_State struct {
+	State *State `protobuf:"bytes,5,opt,name=state,proto3,oneof"`
+}
+
+type Envelope_Error struct {
+	Error *Error `protobuf:"bytes,6,opt,name=error,proto3,oneof"`
+}
+
+type Envelope_Notify struct {
+	Notify *Notify `protobuf:"bytes,7,opt,name=notify,proto3,oneof"`
+}
+
+func (*Envelope_Login) isEnvelope_Payload() {}
+
+func (*Envelope_Assign) isEnvelope_Payload() {}
+
+func (*Envelope_Move) isEnvelope_Payload() {}
+
+func (*Envelope_Chat) isEnvelope_Payload() {}
+
+func (*Envelope_State) isEnvelope_Payload() {}
+
+func (*Envelope_Error) isEnvelope_Payload() {}
+
+func (*Envelope_Notify) isEnvelope_Payload() {}
+
+// 客户端连接后发送的第一条消息. 密码和令牌任选其一.
+// hints 表示客户端同意本局使用 /hint.
+type Login struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          string                 `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	Token         string                 `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`
+	Hints         bool                   `protobuf:"varint,4,opt,name=hints,proto3" json:"hints,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Login) Reset() {
+	*x = Login{}
+	mi := &file_game_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Login) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Login) ProtoMessage() {}
+
+func (x *Login) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Login.ProtoReflect.Descriptor instead.
+func (*Login) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Login) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *Login) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *Login) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *Login) GetHints() bool {
+	if x != nil {
+		return x.Hints
+	}
+	return false
+}
+
+// 服务器分配给客户端的玩家编号, 以及服务器端玩家的用户名.
+// hints 为 true 表示双方都同意, 本局可以使用 /hint; game 为对局编号, 双方的日志用同一个编号.
+type Assign struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Player        Player                 `protobuf:"varint,1,opt,name=player,proto3,enum=tictactoe.v1.Player" json:"player,omitempty"`
+	Opponent      string                 `protobuf:"bytes,2,opt,name=opponent,proto3" json:"opponent,omitempty"`
+	Hints         bool                   `protobuf:"varint,3,opt,name=hints,proto3" json:"hints,omitempty"`
+	Game          string                 `protobuf:"bytes,4,opt,name=game,proto3" json:"game,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Assign) Reset() {
+	*x = Assign{}
+	mi := &file_game_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Assign) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Assign) ProtoMessage() {}
+
+func (x *Assign) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Assign.ProtoReflect.Descriptor instead.
+func (*Assign) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Assign) GetPlayer() Player {
+	if x != nil {
+		return x.Player
+	}
+	return Player_PLAYER_UNSPECIFIED
+}
+
+func (x *Assign) GetOpponent() string {
+	if x != nil {
+		return x.Opponent
+	}
+	return ""
+}
+
+func (x *Assign) GetHints() bool {
+	if x != nil {
+		return x.Hints
+	}
+	return false
+}
+
+func (x *Assign) GetGame() string {
+	if x != nil {
+		return x.Game
+	}
+	return ""
+}
+
+// 落子. x 为行, y 为列, 从 0 开始.
+// player 只是提示, 服务器以连接绑定的身份为准.
+type Move struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Player        Player                 `protobuf:"varint,1,opt,name=player,proto3,enum=tictactoe.v1.Player" json:"player,omitempty"`
+	X             int32                  `protobuf:"varint,2,opt,name=x,proto3" json:"x,omitempty"`
+	Y             int32                  `protobuf:"varint,3,opt,name=y,proto3" json:"y,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Move) Reset() {
+	*x = Move{}
+	mi := &file_game_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Move) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Move) ProtoMessage() {}
+
+func (x *Move) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Move.ProtoReflect.Descriptor instead.
+func (*Move) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Move) GetPlayer() Player {
+	if x != nil {
+		return x.Player
+	}
+	return Player_PLAYER_UNSPECIFIED
+}
+
+func (x *Move) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Move) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+type Chat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Player        Player                 `protobuf:"varint,1,opt,name=player,proto3,enum=tictactoe.v1.Player" json:"player,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chat) Reset() {
+	*x = Chat{}
+	mi := &file_game_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chat) ProtoMessage() {}
+
+func (x *Chat) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chat.ProtoReflect.Descriptor instead.
+func (*Chat) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Chat) GetPlayer() Player {
+	if x != nil {
+		return x.Player
+	}
+	return Player_PLAYER_UNSPECIFIED
+}
+
+func (x *Chat) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+// 权威的对局状态: 轮到谁, 以及是否已经结束.
+// hash 是服务器的局面哈希 (十六进制), 客户端据此核对棋盘.
+type State struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Turn          Player                 `protobuf:"varint,1,opt,name=turn,proto3,enum=tictactoe.v1.Player" json:"turn,omitempty"`
+	Outcome       Outcome                `protobuf:"varint,2,opt,name=outcome,proto3,enum=tictactoe.v1.Outcome" json:"outcome,omitempty"`
+	Hash          string                 `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *State) Reset() {
+	*x = State{}
+	mi := &file_game_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *State) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*State) ProtoMessage() {}
+
+func (x *State) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use State.ProtoReflect.Descriptor instead.
+func (*State) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *State) GetTurn() Player {
+	if x != nil {
+		return x.Turn
+	}
+	return Player_PLAYER_UNSPECIFIED
+}
+
+func (x *State) GetOutcome() Outcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return Outcome_OUTCOME_IN_PROGRESS
+}
+
+func (x *State) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+type Error struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_game_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Error) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type Notify struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Notify) Reset() {
+	*x = Notify{}
+	mi := &file_game_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Notify) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Notify) ProtoMessage() {}
+
+func (x *Notify) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Notify.ProtoReflect.Descriptor instead.
+func (*Notify) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Notify) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+var File_game_proto protoreflect.FileDescriptor
+
+const file_game_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"game.proto\x12\ftictactoe.v1\"\xd0\x02\n" +
+	"\bEnvelope\x12+\n" +
+	"\x05login\x18\x01 \x01(\v2\x13.tictactoe.v1.LoginH\x00R\x05login\x12.\n" +
+	"\x06assign\x18\x02 \x01(\v2\x14.tictactoe.v1.AssignH\x00R\x06assign\x12(\n" +
+	"\x04move\x18\x03 \x01(\v2\x12.tictactoe.v1.MoveH\x00R\x04move\x12(\n" +
+	"\x04chat\x18\x04 \x01(\v2\x12.tictactoe.v1.ChatH\x00R\x04chat\x12+\n" +
+	"\x05state\x18\x05 \x01(\v2\x13.tictactoe.v1.StateH\x00R\x05state\x12+\n" +
+	"\x05error\x18\x06 \x01(\v2\x13.tictactoe.v1.ErrorH\x00R\x05error\x12.\n" +
+	"\x06notify\x18\a \x01(\v2\x14.tictactoe.v1.NotifyH\x00R\x06notifyB\t\n" +
+	"\apayload\"c\n" +
+	"\x05Login\x12\x12\n" +
+	"\x04user\x18\x01 \x01(\tR\x04user\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x14\n" +
+	"\x05token\x18\x03 \x01(\tR\x05token\x12\x14\n" +
+	"\x05hints\x18\x04 \x01(\bR\x05hints\"|\n" +
+	"\x06Assign\x12,\n" +
+	"\x06player\x18\x01 \x01(\x0e2\x14.tictactoe.v1.PlayerR\x06player\x12\x1a\n" +
+	"\bopponent\x18\x02 \x01(\tR\bopponent\x12\x14\n" +
+	"\x05hints\x18\x03 \x01(\bR\x05hints\x12\x12\n" +
+	"\x04game\x18\x04 \x01(\tR\x04game\"P\n" +
+	"\x04Move\x12,\n" +
+	"\x06player\x18\x01 \x01(\x0e2\x14.tictactoe.v1.PlayerR\x06player\x12\f\n" +
+	"\x01x\x18\x02 \x01(\x05R\x01x\x12\f\n" +
+	"\x01y\x18\x03 \x01(\x05R\x01y\"N\n" +
+	"\x04Chat\x12,\n" +
+	"\x06player\x18\x01 \x01(\x0e2\x14.tictactoe.v1.PlayerR\x06player\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\"v\n" +
+	"\x05State\x12(\n" +
+	"\x04turn\x18\x01 \x01(\x0e2\x14.tictactoe.v1.PlayerR\x04turn\x12/\n" +
+	"\aoutcome\x18\x02 \x01(\x0e2\x15.tictactoe.v1.OutcomeR\aoutcome\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash\"!\n" +
+	"\x05Error\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent\"\"\n" +
+	"\x06Notify\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent*@\n" +
+	"\x06Player\x12\x16\n" +
+	"\x12PLAYER_UNSPECIFIED\x10\x00\x12\x0e\n" +
+	"\n" +
+	"PLAYER_ONE\x10\x01\x12\x0e\n" +
+	"\n" +
+	"PLAYER_TWO\x10\x02*n\n" +
+	"\aOutcome\x12\x17\n" +
+	"\x13OUTCOME_IN_PROGRESS\x10\x00\x12\x1b\n" +
+	"\x17OUTCOME_PLAYER_ONE_WINS\x10\x01\x12\x1b\n" +
+	"\x17OUTCOME_PLAYER_TWO_WINS\x10\x02\x12\x10\n" +
+	"\fOUTCOME_DRAW\x10\x032D\n" +
+	"\x06Gomoku\x12:\n" +
+	"\x04Play\x12\x16.tictactoe.v1.Envelope\x1a\x16.tictactoe.v1.Envelope(\x010\x01B\x12Z\x10tictactoe/gamepbb\x06proto3"
+
+var (
+	file_game_proto_rawDescOnce sync.Once
+	file_game_proto_rawDescData []byte
+)
+
+func file_game_proto_rawDescGZIP() []byte {
+	file_game_proto_rawDescOnce.Do(func() {
+		file_game_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_game_proto_rawDesc), len(file_game_proto_rawDesc)))
+	})
+	return file_game_proto_rawDescData
+}
+
+var file_game_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_game_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_game_proto_goTypes = []any{
+	(Player)(0),      // 0: tictactoe.v1.Player
+	(Outcome)(0),     // 1: tictactoe.v1.Outcome
+	(*Envelope)(nil), // 2: tictactoe.v1.Envelope
+	(*Login)(nil),    // 3: tictactoe.v1.Login
+	(*Assign)(nil),   // 4: tictactoe.v1.Assign
+	(*Move)(nil),     // 5: tictactoe.v1.Move
+	(*Chat)(nil),     // 6: tictactoe.v1.Chat
+	(*State)(nil),    // 7: tictactoe.v1.State
+	(*Error)(nil),    // 8: tictactoe.v1.Error
+	(*Notify)(nil),   // 9: tictactoe.v1.Notify
+}
+var file_game_proto_depIdxs = []int32{
+	3,  // 0: tictactoe.v1.Envelope.login:type_name -> tictactoe.v1.Login
+	4,  // 1: tictactoe.v1.Envelope.assign:type_name -> tictactoe.v1.Assign
+	5,  // 2: tictactoe.v1.Envelope.move:type_name -> tictactoe.v1.Move
+	6,  // 3: tictactoe.v1.Envelope.chat:type_name -> tictactoe.v1.Chat
+	7,  // 4: tictactoe.v1.Envelope.state:type_name -> tictactoe.v1.State
+	8,  // 5: tictactoe.v1.Envelope.error:type_name -> tictactoe.v1.Error
+	9,  // 6: tictactoe.v1.Envelope.notify:type_name -> tictactoe.v1.Notify
+	0,  // 7: tictactoe.v1.Assign.player:type_name -> tictactoe.v1.Player
+	0,  // 8: tictactoe.v1.Move.player:type_name -> tictactoe.v1.Player
+	0,  // 9: tictactoe.v1.Chat.player:type_name -> tictactoe.v1.Player
+	0,  // 10: tictactoe.v1.State.turn:type_name -> tictactoe.v1.Player
+	1,  // 11: tictactoe.v1.State.outcome:type_name -> tictactoe.v1.Outcome
+	2,  // 12: tictactoe.v1.Gomoku.Play:input_type -> tictactoe.v1.Envelope
+	2,  // 13: tictactoe.v1.Gomoku.Play:output_type -> tictactoe.v1.Envelope
+	13, // [13:14] is the sub-list for method output_type
+	12, // [12:13] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_game_proto_init() }
+func file_game_proto_init() {
+	if File_game_proto != nil {
+		return
+	}
+	file_game_proto_msgTypes[0].OneofWrappers = []any{
+		(*Envelope_Login)(nil),
+		(*Envelope_Assign)(nil),
+		(*Envelope_Move)(nil),
+		(*Envelope_Chat)(nil),
+		(*Envelope_State)(nil),
+		(*Envelope_Error)(nil),
+		(*Envelope_Notify)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_game_proto_rawDesc), len(file_game_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_game_proto_goTypes,
+		DependencyIndexes: file_game_proto_depIdxs,
+		EnumInfos:         file_game_proto_enumTypes,
+		MessageInfos:      file_game_proto_msgTypes,
+	}.Build()
+	File_game_proto = out.File
+	file_game_proto_goTypes = nil
+	file_game_proto_depIdxs = nil
+}
@@ -0,0 +1,126 @@
+// game.proto
+//
+// gRPC 版本的对局协议, 与 TCP/WebSocket 上的 JSON Message 一一对应.
+// 生成代码: 在仓库根目录运行 `buf generate` (见 buf.gen.yaml).
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: game.proto
+
+package gamepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Gomoku_Play_FullMethodName = "/tictactoe.v1.Gomoku/Play"
+)
+
+// GomokuClient is the client API for Gomoku service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 对局服务. Play 是双向流: 客户端先发送 Login, 服务器回复 Assign 或 Error,
+// 之后双方互相发送 Move / Chat / State / Error / Notify.
+type GomokuClient interface {
+	Play(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Envelope, Envelope], error)
+}
+
+type gomokuClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGomokuClient(cc grpc.ClientConnInterface) GomokuClient {
+	return &gomokuClient{cc}
+}
+
+func (c *gomokuClient) Play(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Envelope, Envelope], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Gomoku_ServiceDesc.Streams[0], Gomoku_Play_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Envelope, Envelope]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Gomoku_PlayClient = grpc.BidiStreamingClient[Envelope, Envelope]
+
+// GomokuServer is the server API for Gomoku service.
+// All implementations must embed UnimplementedGomokuServer
+// for forward compatibility.
+//
+// 对局服务. Play 是双向流: 客户端先发送 Login, 服务器回复 Assign 或 Error,
+// 之后双方互相发送 Move / Chat / State / Error / Notify.
+type GomokuServer interface {
+	Play(grpc.BidiStreamingServer[Envelope, Envelope]) error
+	mustEmbedUnimplementedGomokuServer()
+}
+
+// UnimplementedGomokuServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGomokuServer struct{}
+
+func (UnimplementedGomokuServer) Play(grpc.BidiStreamingServer[Envelope, Envelope]) error {
+	return status.Error(codes.Unimplemented, "method Play not implemented")
+}
+func (UnimplementedGomokuServer) mustEmbedUnimplementedGomokuServer() {}
+func (UnimplementedGomokuServer) testEmbeddedByValue()                {}
+
+// UnsafeGomokuServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GomokuServer will
+// result in compilation errors.
+type UnsafeGomokuServer interface {
+	mustEmbedUnimplementedGomokuServer()
+}
+
+func RegisterGomokuServer(s grpc.ServiceRegistrar, srv GomokuServer) {
+	// If the following call panics, it indicates UnimplementedGomokuServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Gomoku_ServiceDesc, srv)
+}
+
+func _Gomoku_Play_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GomokuServer).Play(&grpc.GenericServerStream[Envelope, Envelope]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Gomoku_PlayServer = grpc.BidiStreamingServer[Envelope, Envelope]
+
+// Gomoku_ServiceDesc is the grpc.ServiceDesc for Gomoku service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Gomoku_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "tictactoe.v1.Gomoku",
+	HandlerType: (*GomokuServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Play",
+			Handler:       _Gomoku_Play_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "game.proto",
+}
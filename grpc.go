@@ -0,0 +1,218 @@
+// grpc.go
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"tictactoe/gamepb"
+)
+
+// --- Message 与 protobuf 之间的转换 ---
+
+// 把 JSON 协议的 Message 转换为 gRPC 的 Envelope
+func messageToEnvelope(msg Message) *gamepb.Envelope {
+	player := gamepb.Player(msg.Player)
+	switch msg.Type {
+	case MsgTypeLogin:
+		return &gamepb.Envelope{Payload: &gamepb.Envelope_Login{Login: &gamepb.Login{
+			User: msg.User, Password: msg.Password, Token: msg.Token, Hints: msg.Hints,
+		}}}
+	case MsgTypeAssign:
+		return &gamepb.Envelope{Payload: &gamepb.Envelope_Assign{Assign: &gamepb.Assign{
+			Player: player, Opponent: msg.User, Hints: msg.Hints, Game: msg.Game,
+		}}}
+	case MsgTypeMove:
+		return &gamepb.Envelope{Payload: &gamepb.Envelope_Move{Move: &gamepb.Move{
+			Player: player, X: int32(msg.X), Y: int32(msg.Y),
+		}}}
+	case MsgTypeChat:
+		return &gamepb.Envelope{Payload: &gamepb.Envelope_Chat{Chat: &gamepb.Chat{
+			Player: player, Content: msg.Content,
+		}}}
+	case MsgTypeState:
+		return &gamepb.Envelope{Payload: &gamepb.Envelope_State{State: &gamepb.State{
+			Turn: gamepb.Player(msg.Turn), Outcome: gamepb.Outcome(msg.Winner), Hash: msg.Hash,
+		}}}
+	case MsgTypeError:
+		return &gamepb.Envelope{Payload: &gamepb.Envelope_Error{Error: &gamepb.Error{Content: msg.Content}}}
+	case MsgTypeNotify:
+		return &gamepb.Envelope{Payload: &gamepb.Envelope_Notify{Notify: &gamepb.Notify{Content: msg.Content}}}
+	}
+	return nil
+}
+
+// 把 gRPC 的 Envelope 转换为 JSON 协议的 Message
+func envelopeToMessage(env *gamepb.Envelope) (Message, error) {
+	switch p := env.GetPayload().(type) {
+	case *gamepb.Envelope_Login:
+		return Message{Type: MsgTypeLogin, User: p.Login.GetUser(), Password: p.Login.GetPassword(), Token: p.Login.GetToken(), Hints: p.Login.GetHints()}, nil
+	case *gamepb.Envelope_Assign:
+		return Message{Type: MsgTypeAssign, Player: int(p.Assign.GetPlayer()), User: p.Assign.GetOpponent(), Hints: p.Assign.GetHints(), Game: p.Assign.GetGame()}, nil
+	case *gamepb.Envelope_Move:
+		return Message{Type: MsgTypeMove, Player: int(p.Move.GetPlayer()), X: int(p.Move.GetX()), Y: int(p.Move.GetY())}, nil
+	case *gamepb.Envelope_Chat:
+		return Message{Type: MsgTypeChat, Player: int(p.Chat.GetPlayer()), Content: p.Chat.GetContent()}, nil
+	case *gamepb.Envelope_State:
+		return Message{Type: MsgTypeState, Turn: int(p.State.GetTurn()), Winner: int(p.State.GetOutcome()), Hash: p.State.GetHash()}, nil
+	case *gamepb.Envelope_Error:
+		return Message{Type: MsgTypeError, Content: p.Error.GetContent()}, nil
+	case *gamepb.Envelope_Notify:
+		return Message{Type: MsgTypeNotify, Content: p.Notify.GetContent()}, nil
+	}
+	return Message{}, errors.New("envelope without payload")
+}
+
+// --- gRPC 传输层 ---
+
+// 服务器端和客户端的流都满足这个接口
+type envelopeStream interface {
+	Send(*gamepb.Envelope) error
+	Recv() (*gamepb.Envelope, error)
+}
+
+// gRPC 双向流上的 Transport
+type grpcTransport struct {
+	stream  envelopeStream
+	remote  net.Addr
+	sendMu  sync.Mutex // 同一个流不允许并发 Send
+	closeFn func()     // 结束流 (服务器: 让 handler 返回; 客户端: 取消 context)
+
+	timerMu sync.Mutex
+	timer   *time.Timer // 模拟读超时: 到期后结束流
+}
+
+func (t *grpcTransport) Send(msg Message) error {
+	env := messageToEnvelope(msg)
+	if env == nil {
+		return errors.New("message type not supported over gRPC: " + msg.Type)
+	}
+	t.sendMu.Lock()
+	defer t.sendMu.Unlock()
+	return t.stream.Send(env)
+}
+
+func (t *grpcTransport) Receive(msg *Message) error {
+	env, err := t.stream.Recv()
+	if status.Code(err) == codes.Canceled {
+		return io.EOF // 本地或对端结束了流, 与 TCP 连接关闭的处理保持一致
+	}
+	if err != nil {
+		return err // 对端关闭发送方向时为 io.EOF
+	}
+	*msg, err = envelopeToMessage(env)
+	return err
+}
+
+// gRPC 流没有读超时, 用定时器在到期时结束整个流
+func (t *grpcTransport) SetReadDeadline(deadline time.Time) error {
+	t.timerMu.Lock()
+	defer t.timerMu.Unlock()
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	if !deadline.IsZero() {
+		t.timer = time.AfterFunc(time.Until(deadline), t.closeFn)
+	}
+	return nil
+}
+
+func (t *grpcTransport) RemoteAddr() net.Addr { return t.remote }
+
+func (t *grpcTransport) Close() error {
+	t.closeFn()
+	return nil
+}
+
+// gRPC 服务: 每个 Play 流作为一个新连接交给主流程
+type grpcGameServer struct {
+	gamepb.UnimplementedGomokuServer
+	incoming chan<- Transport
+}
+
+func (s *grpcGameServer) Play(stream gamepb.Gomoku_PlayServer) error {
+	done := make(chan struct{})
+	var once sync.Once
+	t := &grpcTransport{
+		stream:  stream,
+		closeFn: func() { once.Do(func() { close(done) }) },
+	}
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		t.remote = p.Addr
+	}
+
+	countConnection("grpc")
+	select {
+	case s.incoming <- t:
+	case <-stream.Context().Done():
+		return stream.Context().Err()
+	}
+	// handler 返回即结束流, 所以一直等到连接被关闭
+	select {
+	case <-done:
+	case <-stream.Context().Done():
+	}
+	return nil
+}
+
+// 在 addr 上提供 gRPC 服务; tlsConfig 非空时使用 TLS. 服务停止时返回原因
+func serveGRPC(addr string, tlsConfig *tls.Config, incoming chan<- Transport) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen for gRPC: %w", err)
+	}
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	server := grpc.NewServer(opts...)
+	gamepb.RegisterGomokuServer(server, &grpcGameServer{incoming: incoming})
+	err = server.Serve(listener)
+	return fmt.Errorf("gRPC server stopped: %w", err)
+}
+
+// 客户端: 连接 gRPC 服务器并打开对局流; tlsConfig 为空时不加密
+func dialGRPC(addr string, tlsConfig *tls.Config) (Transport, error) {
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := gamepb.NewGomokuClient(conn).Play(ctx)
+	if err != nil {
+		cancel()
+		conn.Close()
+		return nil, err
+	}
+	remote, _ := net.ResolveTCPAddr("tcp", addr)
+	var once sync.Once
+	return &grpcTransport{
+		stream: stream,
+		remote: remote,
+		closeFn: func() {
+			once.Do(func() {
+				stream.CloseSend()
+				cancel()
+				conn.Close()
+			})
+		},
+	}, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"tictactoe/gamepb"
+)
+
+// 每种消息经过 Envelope 转换后应当原样还原
+func TestEnvelopeRoundTrip(t *testing.T) {
+	msgs := []Message{
+		{Type: MsgTypeLogin, User: "alice", Password: "pw", Token: "tok", Hints: true},
+		{Type: MsgTypeAssign, Player: Player2, User: "bob", Hints: true, Game: "3f2a9c1b"},
+		{Type: MsgTypeMove, Player: Player1, X: 7, Y: 8},
+		{Type: MsgTypeChat, Player: Player2, Content: "/me waves"},
+		{Type: MsgTypeState, Turn: Player2, Hash: "00c0ffee12345678"},
+		{Type: MsgTypeState, Winner: Draw, Hash: "0123456789abcdef"},
+		{Type: MsgTypeError, Content: "Received invalid move"},
+		{Type: MsgTypeNotify, Content: notifyHintUsed},
+	}
+	for _, want := range msgs {
+		env := messageToEnvelope(want)
+		if env == nil {
+			t.Errorf("messageToEnvelope(%+v) = nil", want)
+			continue
+		}
+		got, err := envelopeToMessage(env)
+		if err != nil {
+			t.Errorf("envelopeToMessage(%v): %v", env, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("round trip of %+v gave %+v", want, got)
+		}
+	}
+	if env := messageToEnvelope(Message{Type: msgTypeClosed}); env != nil {
+		t.Errorf("local-only message converted to %v", env)
+	}
+	if _, err := envelopeToMessage(&gamepb.Envelope{}); err == nil {
+		t.Error("envelope without payload converted without error")
+	}
+}
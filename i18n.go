@@ -0,0 +1,162 @@
+// i18n.go
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// 界面文本的翻译目录, 每种语言一个 JSON 文件 (键 -> fmt 格式串)
+// 复数形式用 ".one" / ".other" 后缀区分, 见 Tn
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+const defaultLanguage = "en"
+
+var (
+	catalogs = loadCatalogs()
+	language = defaultLanguage // 当前语言, 由 SetLanguage 设置
+)
+
+// 读取内置的全部目录, 以文件名 (去掉 .json) 作为语言标签
+func loadCatalogs() map[string]map[string]string {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	result := make(map[string]map[string]string)
+	for _, e := range entries {
+		data, err := localeFiles.ReadFile("locales/" + e.Name())
+		if err != nil {
+			panic(err)
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("locale %s: %v", e.Name(), err))
+		}
+		result[strings.TrimSuffix(e.Name(), path.Ext(e.Name()))] = catalog
+	}
+	return result
+}
+
+// 把 zh_CN.UTF-8, zh-cn 之类的写法规范成目录的标签; 找不到时按语言部分匹配 (zh -> zh-CN)
+func matchLanguage(tag string) (string, bool) {
+	tag, _, _ = strings.Cut(tag, ".") // 去掉编码
+	tag, _, _ = strings.Cut(tag, "@") // 去掉修饰 (如 @euro)
+	tag = strings.ReplaceAll(tag, "_", "-")
+	if tag == "" || tag == "C" || tag == "POSIX" {
+		return defaultLanguage, false
+	}
+	base, _, _ := strings.Cut(tag, "-")
+	var baseMatch string
+	for name := range catalogs {
+		if strings.EqualFold(name, tag) {
+			return name, true
+		}
+		if nameBase, _, _ := strings.Cut(name, "-"); strings.EqualFold(nameBase, base) && (baseMatch == "" || name < baseMatch) {
+			baseMatch = name
+		}
+	}
+	if baseMatch != "" {
+		return baseMatch, true
+	}
+	return defaultLanguage, false
+}
+
+// 选择界面语言: 优先 --lang, 其次 LC_ALL, LC_MESSAGES, LANG 环境变量
+func SetLanguage(flagValue string) {
+	if flagValue != "" {
+		lang, ok := matchLanguage(flagValue)
+		if !ok {
+			slog.Warn("No translation for language", "lang", flagValue, "using", defaultLanguage)
+		}
+		language = lang
+		return
+	}
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(env); v != "" {
+			language, _ = matchLanguage(v) // 与 POSIX 一致: 第一个非空的变量生效
+			return
+		}
+	}
+	language = defaultLanguage
+}
+
+// 查找文本: 当前语言 -> 英文 -> 键本身
+func lookup(key string) string {
+	if s, ok := catalogs[language][key]; ok {
+		return s
+	}
+	if s, ok := catalogs[defaultLanguage][key]; ok {
+		return s
+	}
+	return key
+}
+
+// 翻译并格式化界面文本
+func T(key string, args ...any) string {
+	if len(args) == 0 {
+		return lookup(key)
+	}
+	return fmt.Sprintf(lookup(key), args...)
+}
+
+// 带数量的文本: 按当前语言的复数规则选择 key.one 或 key.other,
+// n 作为第一个格式参数 (格式串中用 %[1]d 引用), 其余参数依次在后
+func Tn(key string, n int, args ...any) string {
+	form := "other"
+	if pluralOne(language, n) {
+		form = "one"
+	}
+	if _, ok := catalogs[language][key+"."+form]; !ok {
+		form = "other" // 没有单数形式的语言 (如中文) 只提供 other
+	}
+	return fmt.Sprintf(lookup(key+"."+form), append([]any{n}, args...)...)
+}
+
+// 复数规则 (CLDR 的简化版): 中文, 日文等不区分单复数
+func pluralOne(lang string, n int) bool {
+	base, _, _ := strings.Cut(lang, "-")
+	switch base {
+	case "zh", "ja", "ko":
+		return false
+	}
+	return n == 1
+}
+
+// --- 终端显示宽度 ---
+
+// 一个字符在终端中占的列数: 东亚宽字符 (中日韩文字, 全角标点) 占 2 列
+func runeWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.Is(unicode.Mn, r):
+		return 0
+	case r >= 0x1100 && r <= 0x115F, // 谚文字母
+		r >= 0x2E80 && r <= 0xA4CF && r != 0x303F, // 中日韩部首到彝文
+		r >= 0xAC00 && r <= 0xD7A3,                // 谚文音节
+		r >= 0xF900 && r <= 0xFAFF,                // 兼容汉字
+		r >= 0xFE30 && r <= 0xFE4F,                // 竖排标点
+		r >= 0xFF00 && r <= 0xFF60,                // 全角字符
+		r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F300 && r <= 0x1F64F, // 表情符号
+		r >= 0x20000 && r <= 0x3FFFD: // 扩展汉字
+		return 2
+	}
+	return 1
+}
+
+// 字符串在终端中占的列数
+func stringWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w
+}
@@ -0,0 +1,148 @@
+// logging.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// 日志: log/slog 结构化日志, 级别由 --log-level 控制, --log-file 把日志写到文件, 不与终端上的棋盘混在一起.
+// 对局相关的日志带上同样的字段: game (对局编号), player (本地玩家), move (已有的手数), 见 GameState.logger
+
+// 默认记录器写到标准错误时的级别; 写到 --log-file 或还没有调用 setupLogging 时为 nil
+var stderrLogLevel slog.Leveler
+
+func parseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return level, fmt.Errorf("invalid log level %q (available: debug, info, warn, error)", s)
+	}
+	return level, nil
+}
+
+// 设置默认的 slog 记录器. level 为空时: 写到文件时为 info; 写到终端时为 warn,
+// 以免信息日志打乱对局画面. 标准库的 log 只用于 log.Fatal (启动失败等), 仍然写到标准错误.
+// 返回的函数关闭日志文件
+func setupLogging(level, file string) (func(), error) {
+	var w io.Writer = os.Stderr
+	closeFn := func() {}
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open log file: %w", err)
+		}
+		w, closeFn = f, func() { f.Close() }
+	}
+	if level == "" {
+		level = "info"
+		if file == "" && term.IsTerminal(int(os.Stderr.Fd())) {
+			level = "warn"
+		}
+	}
+	l, err := parseLogLevel(level)
+	if err != nil {
+		closeFn()
+		return nil, err
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: l})))
+	stderrLogLevel = nil
+	if file == "" {
+		stderrLogLevel = l
+	}
+	// SetDefault 会把 log 的输出转到 slog (按 info 级别), 日志写到文件或级别为 warn 时
+	// 终端上就看不到退出的原因; 改回直接写标准错误
+	log.SetOutput(os.Stderr)
+	log.SetFlags(log.LstdFlags)
+	return closeFn, nil
+}
+
+// 全屏界面运行期间, 写到标准错误的日志会盖住棋盘: 这时把默认记录器换成写到 w 的 (级别不变),
+// 返回恢复原来记录器的函数. 日志本来就写到文件时什么也不做
+func redirectStderrLog(w io.Writer) (restore func()) {
+	if stderrLogLevel == nil {
+		return func() {}
+	}
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: stderrLogLevel})))
+	// SetDefault 又把 log 的输出转到了 slog, 同 setupLogging 一样改回直接写
+	log.SetOutput(w)
+	log.SetFlags(log.LstdFlags)
+	return func() {
+		slog.SetDefault(old)
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}
+}
+
+// 把逐行写入的文本 (例如外部引擎的标准错误) 作为 info 日志记录, 每行一条
+type logLineWriter struct {
+	logger  *slog.Logger
+	msg     string
+	partial []byte // 还没有换行的部分
+}
+
+func (w *logLineWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(w.partial[:i])); line != "" {
+			w.logger.Info(w.msg, "line", line)
+		}
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// 对局的日志记录器, 带对局编号, 本地玩家和手数 (需要在外部加锁调用)
+func (gs *GameState) loggerInternal() *slog.Logger {
+	return slog.With("game", gs.gameID, "player", gs.playerID, "move", len(gs.moves))
+}
+
+func (gs *GameState) logger() *slog.Logger {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	return gs.loggerInternal()
+}
+
+// 日志中显示的消息: 只列出用到的字段, 隐去登录密码和令牌
+func (m Message) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("type", m.Type)}
+	if m.Player != 0 {
+		attrs = append(attrs, slog.Int("player", m.Player))
+	}
+	if m.Type == MsgTypeMove {
+		attrs = append(attrs, slog.Int("x", m.X), slog.Int("y", m.Y))
+	}
+	if m.Content != "" {
+		attrs = append(attrs, slog.String("content", m.Content))
+	}
+	if m.Turn != 0 || m.Winner != 0 {
+		attrs = append(attrs, slog.Int("turn", m.Turn), slog.Int("winner", m.Winner))
+	}
+	if m.User != "" {
+		attrs = append(attrs, slog.String("user", m.User))
+	}
+	if m.Password != "" {
+		attrs = append(attrs, slog.String("password", "***"))
+	}
+	if m.Token != "" {
+		attrs = append(attrs, slog.String("token", "***"))
+	}
+	if m.Game != "" {
+		attrs = append(attrs, slog.String("game", m.Game))
+	}
+	if m.Hash != "" {
+		attrs = append(attrs, slog.String("hash", m.Hash))
+	}
+	return slog.GroupValue(attrs...)
+}
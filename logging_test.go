@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 日志写到文件时, log.Fatal 等标准库 log 的输出仍然写到标准错误
+func TestSetupLoggingKeepsStdLogOnStderr(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	closeLog, err := setupLogging("warn", filepath.Join(t.TempDir(), "game.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeLog()
+	if log.Writer() != os.Stderr {
+		t.Errorf("log.Writer() = %T, want os.Stderr", log.Writer())
+	}
+	if _, err := setupLogging("loud", ""); err == nil {
+		t.Error("setupLogging with an unknown level succeeded, want error")
+	}
+}
+
+// 外部引擎的标准错误按行写入日志, 不直接出现在终端上
+func TestLogLineWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := &logLineWriter{logger: slog.New(slog.NewTextHandler(&buf, nil)), msg: "Engine stderr"}
+	io.WriteString(w, "first line\nsecond ")
+	io.WriteString(w, "line\n\n")
+	out := buf.String()
+	if n := strings.Count(out, "msg=\"Engine stderr\""); n != 2 {
+		t.Errorf("logged %d lines, want 2:\n%s", n, out)
+	}
+	if !strings.Contains(out, `line="first line"`) || !strings.Contains(out, `line="second line"`) {
+		t.Errorf("lines not logged as written:\n%s", out)
+	}
+}
+
+// 全屏界面运行期间, 本来写到标准错误的日志改写到界面的日志文件, 关闭界面后恢复
+func TestRedirectStderrLog(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	defer log.SetOutput(os.Stderr)
+
+	closeLog, err := setupLogging("info", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeLog()
+	stderrLogger := slog.Default()
+	var buf bytes.Buffer
+	restore := redirectStderrLog(&buf)
+	slog.Warn("Engine crashed", "engine", "test")
+	slog.Debug("below the level")
+	if out := buf.String(); !strings.Contains(out, `msg="Engine crashed" engine=test`) || strings.Contains(out, "below the level") {
+		t.Errorf("redirected log = %q, want only the warning", out)
+	}
+	if log.Writer() != &buf {
+		t.Errorf("log.Writer() = %T during the TUI, want the TUI log", log.Writer())
+	}
+	restore()
+	if slog.Default() != stderrLogger || log.Writer() != os.Stderr {
+		t.Error("restore did not bring back the stderr logger")
+	}
+
+	// 有 --log-file 时不改动
+	closeLog, err = setupLogging("info", filepath.Join(t.TempDir(), "game.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeLog()
+	fileLogger := slog.Default()
+	buf.Reset()
+	restore = redirectStderrLog(&buf)
+	slog.Warn("Engine crashed")
+	restore()
+	if buf.Len() != 0 || slog.Default() != fileLogger {
+		t.Errorf("logging to --log-file was redirected: %q", buf.String())
+	}
+}
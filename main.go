@@ -3,17 +3,22 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"io" // 需要导入 io 包处理 EOF
 	"log"
+	"log/slog"
 	"net"
 	"os"
-	"strconv"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/term"
 )
 
 const (
@@ -31,37 +36,68 @@ const (
 	MsgTypeAssign = "assign" // 分配玩家编号
 	MsgTypeError  = "error"  // 错误消息
 	MsgTypeNotify = "notify" // 通用通知 (例如对方已移动)
+	MsgTypeLogin  = "login"  // 登录 (客户端连接后发送的第一条消息)
+
+	notifyHintUsed = "hint" // 通知的内容: 对方使用了 /hint
+
+	msgTypeClosed = "closed" // 连接已断开; 只在本地由 networkReceiver 送给主循环, 不会发送
 )
 
 // 网络消息结构体
 type Message struct {
-	Type    string `json:"type"`              // 消息类型
-	Player  int    `json:"player"`            // 发送者玩家编号 (1 or 2)
-	X       int    `json:"x,omitempty"`       // 移动的 X 坐标
-	Y       int    `json:"y,omitempty"`       // 移动的 Y 坐标
-	Content string `json:"content,omitempty"` // 聊天内容 或 状态描述 或 错误信息 或通知
-	Turn    int    `json:"turn,omitempty"`    // 当前轮到谁
-	Winner  int    `json:"winner,omitempty"`  // 获胜者 (0: 进行中, 1: Player1, 2: Player2, 3: 平局)
+	Type     string `json:"type"`               // 消息类型
+	Player   int    `json:"player"`             // 发送者玩家编号 (1 or 2)
+	X        int    `json:"x,omitempty"`        // 移动的 X 坐标
+	Y        int    `json:"y,omitempty"`        // 移动的 Y 坐标
+	Content  string `json:"content,omitempty"`  // 聊天内容 或 状态描述 或 错误信息 或通知
+	Turn     int    `json:"turn,omitempty"`     // 当前轮到谁
+	Winner   int    `json:"winner,omitempty"`   // 获胜者 (0: 进行中, 1: Player1, 2: Player2, 3: 平局)
+	User     string `json:"user,omitempty"`     // 登录用户名, 或分配消息中对方的用户名
+	Password string `json:"password,omitempty"` // 登录密码 (仅用于 login)
+	Token    string `json:"token,omitempty"`    // 预共享令牌 (仅用于 login)
+	Hash     string `json:"hash,omitempty"`     // 服务器的局面哈希 (仅用于 state), 客户端据此核对棋盘
+	Game     string `json:"game,omitempty"`     // 对局编号 (仅用于 assign), 双方的日志用同一个编号
+	Hints    bool   `json:"hints,omitempty"`    // login: 客户端同意使用 /hint; assign: 双方都同意, 本局可以使用
 }
 
 // 游戏状态
 type GameState struct {
-	board          [][]int
-	currentPlayer  int
-	winner         int // 0: 进行中, 1: Player1, 2: Player2, 3: 平局
-	gameOver       bool
+	Game                      // 棋盘, 回合和胜负 (由 mu 保护)
 	mu             sync.Mutex // 用于保护棋盘和游戏状态的并发访问
-	conn           net.Conn   // 网络连接
+	conn           Transport  // 网络连接 (TCP/TLS 或 WebSocket)
 	playerID       int        // 当前实例是玩家1还是玩家2
-	encoder        *json.Encoder
-	decoder        *json.Decoder
-	chatHistory    []string
-	chatMu         sync.Mutex    // 保护聊天记录
-	needsRedraw    bool          // 标记是否需要重新绘制屏幕
-	redrawMu       sync.Mutex    // 保护 needsRedraw
-	inputChan      chan string   // 用于从标准输入读取
-	networkMsgChan chan Message  // 用于从网络读取
-	quitChan       chan struct{} // 用于通知goroutine退出
+	isServer       bool       // 是否为服务器 (服务器是游戏状态的权威方)
+	userName       string     // 本地用户名
+	peerID         int        // 连接对端绑定的玩家编号 (不信任消息中的 Player 字段)
+	peerName       string     // 连接对端的用户名
+	chatHistory    []ChatEntry
+	showAllChat    bool            // 逐行界面下次绘制时显示全部聊天记录 (/history)
+	muted          map[string]bool // /mute 屏蔽的用户名 (由 chatMu 保护)
+	chatFilter     *WordFilter     // 屏蔽词 (--chat-filter), 为 nil 时不过滤
+	chatLimiter    *rateLimiter    // 服务器对对方聊天的限速
+	chatMu         sync.Mutex      // 保护聊天记录
+	needsRedraw    bool            // 标记是否需要重新绘制屏幕
+	notice         string          // 给用户的提示 (例如输入错误), 下次输入时清除
+	redrawMu       sync.Mutex      // 保护 needsRedraw 和 notice
+	tui            *TUI            // 全屏界面; 为 nil 时使用逐行打印的界面
+	renderer       Renderer        // 棋盘的绘制方式 (--theme)
+	notation       Notation        // 坐标记法 (--notation)
+	hint           *Analysis       // 最近一次 /hint 的分析 (由 mu 保护)
+	hintMoveNum    int             // hint 对应的手数, 之后有人落子就不再显示
+	allowHints     bool            // 本地同意在联网对局中使用 /hint (--allow-hints)
+	hintsOK        bool            // 本局可以使用 /hint: 对手是引擎, 或双方都同意 (由 mu 保护)
+	engine         Engine          // 代替本地玩家落子的引擎 (--engine), 为 nil 时由用户输入
+	engineMoveNum  int             // 已经请求引擎落子的局面 (手数), 避免重复请求
+	inputChan      chan string     // 用于从标准输入读取
+	networkMsgChan chan Message    // 用于从网络读取
+	quitChan       chan struct{}   // 用于通知goroutine退出
+	ended          atomic.Bool     // 对局已经结束并在聊天区写下了结果; 之后留在结束画面, 直到用户 /quit
+	review         *Review         // /review 的结果, 为 nil 时还没有复盘 (由 mu 保护)
+	reviewPos      int             // 复盘时显示的局面 (手数), -1 表示不在复盘, -2 表示正在后台复盘
+	reviewLine     int             // 在所选局面上摆出的最佳变化的手数 (/review line), 换局面时清零
+	events         *EventLog       // 事件流 (--events), 为 nil 时不输出
+	gameID         string          // 对局编号, 用于日志; 由服务器生成, 客户端在分配时收到
+	started        time.Time       // 本地玩家的编号确定 (对局开始) 的时间, 用于统计对局时长
 }
 
 // 设置需要重绘的标志
@@ -80,6 +116,21 @@ func (gs *GameState) CheckAndResetRedraw() bool {
 	return needs
 }
 
+// 设置提示信息并请求重绘 (提示显示在状态栏或棋盘下方, 不会被清屏冲掉)
+func (gs *GameState) ShowNotice(text string) {
+	gs.redrawMu.Lock()
+	gs.notice = text
+	gs.needsRedraw = true
+	gs.redrawMu.Unlock()
+}
+
+// 当前的提示信息
+func (gs *GameState) Notice() string {
+	gs.redrawMu.Lock()
+	defer gs.redrawMu.Unlock()
+	return gs.notice
+}
+
 // --- 游戏逻辑 ---
 
 // 初始化棋盘
@@ -91,49 +142,39 @@ func NewBoard(size int) [][]int {
 	return board
 }
 
-// 打印棋盘到控制台 (需要加锁)
-func (gs *GameState) DisplayBoard() {
-	gs.mu.Lock()
-	defer gs.mu.Unlock() // 确保函数退出时解锁
-
-	// 清屏 (简单的实现，可能在不同终端效果不同)
-	// fmt.Print("\033[H\033[2J") // ANSI 清屏序列
-
-	fmt.Print("\n   ") // 列号上方留空
-	for j := 0; j < BoardSize; j++ {
-		fmt.Printf("%2d ", j)
+// 当前棋盘的快照, 带 /hint 标记, 不含光标等高亮 (需要在外部加锁调用)
+func (gs *GameState) boardViewInternal() BoardView {
+	if view, ok := gs.reviewViewInternal(); ok {
+		return view
 	}
-	fmt.Println()
-	fmt.Print("  +-")
-	for j := 0; j < BoardSize; j++ {
-		fmt.Print("--+")
+	board := NewBoard(BoardSize)
+	for i := range gs.board {
+		copy(board[i], gs.board[i])
 	}
-	fmt.Println()
-
-	for i := 0; i < BoardSize; i++ {
-		fmt.Printf("%2d|", i) // 行号
-		for j := 0; j < BoardSize; j++ {
-			switch gs.board[i][j] {
-			case Empty:
-				fmt.Print(" . ")
-			case Player1:
-				fmt.Print(" X ") // 玩家1 使用 X
-			case Player2:
-				fmt.Print(" O ") // 玩家2 使用 O
-			}
-		}
-		fmt.Printf("|%d\n", i) // 行号
+	var last *Move
+	if n := len(gs.moves); n > 0 {
+		m := gs.moves[n-1]
+		last = &m
 	}
-	fmt.Print("  +-")
-	for j := 0; j < BoardSize; j++ {
-		fmt.Print("--+")
+	view := NewBoardView(board, last)
+	view.Notation = gs.notation
+	if gs.hint != nil && gs.hintMoveNum == len(gs.moves) {
+		view.Marks = gs.hint.Marks()
 	}
+	return view
+}
+
+// 用选定的渲染器打印棋盘到控制台 (需要加锁)
+func (gs *GameState) DisplayBoard() {
+	gs.mu.Lock()
+	view := gs.boardViewInternal()
+	gs.mu.Unlock() // 绘制时不持锁
+
 	fmt.Println()
-	fmt.Print("   ") // 列号下方
-	for j := 0; j < BoardSize; j++ {
-		fmt.Printf("%2d ", j)
+	for _, line := range gs.renderer.RenderBoard(view) {
+		fmt.Println(line)
 	}
-	fmt.Println("\n")
+	fmt.Println()
 }
 
 // 检查是否获胜 (无锁的核心逻辑)
@@ -173,111 +214,93 @@ func checkDrawLogic(board [][]int) bool {
 	return true
 }
 
-// 尝试落子 (需要在外部加锁调用)
-func (gs *GameState) placePieceInternal(x, y, player int) bool {
-	if x < 0 || x >= BoardSize || y < 0 || y >= BoardSize || gs.board[x][y] != Empty {
-		return false
-	}
-	gs.board[x][y] = player
-	return true
-}
-
-// 添加聊天消息 (需要加锁)
-func (gs *GameState) AddChatMessage(sender string, message string) {
-	gs.chatMu.Lock()
-	defer gs.chatMu.Unlock()
-	gs.chatHistory = append(gs.chatHistory, fmt.Sprintf("[%s]: %s", sender, message))
-	const maxChatHistory = 20
-	if len(gs.chatHistory) > maxChatHistory {
-		gs.chatHistory = gs.chatHistory[len(gs.chatHistory)-maxChatHistory:]
-	}
-}
-
-// 显示聊天记录 (需要加锁)
-func (gs *GameState) DisplayChat() {
-	gs.chatMu.Lock()
-	defer gs.chatMu.Unlock()
-	fmt.Println("--- Chat ---")
-	if len(gs.chatHistory) == 0 {
-		fmt.Println("(No messages yet)")
-	} else {
-		for _, msg := range gs.chatHistory {
-			fmt.Println(msg)
-		}
-	}
-	fmt.Println("------------")
-}
-
 // --- 网络处理 ---
 
+// 标准输入的共享读取器 (登录前的提示和 inputReader 共用, 避免缓冲数据丢失)
+var stdinReader = bufio.NewReader(os.Stdin)
+
 // 发送消息 (不在锁内调用)
 func (gs *GameState) SendMessage(msg Message) error {
 	if gs.conn == nil {
 		return fmt.Errorf("no connection established")
 	}
-	// log.Printf("DEBUG: Sending message: %+v\n", msg)
-	// 对网络连接的写操作本身应该是线程安全的，但最好还是避免并发写同一个 encoder
-	// 如果担心并发写 encoder，可以在这里加一个单独的发送锁
-	err := gs.encoder.Encode(msg)
+	logger := gs.logger()
+	logger.Debug("Sending message", "msg", msg)
+	// Transport 内部有发送锁, 可以从多个 goroutine 并发调用
+	err := gs.conn.Send(msg)
 	if err != nil {
-		log.Printf("Error sending message: %v", err)
-		// 触发游戏结束流程
-		close(gs.quitChan) // 通知其他 goroutine 退出
+		logger.Error("Error sending message", "type", msg.Type, "err", err)
+		gs.events.Emit(errorEvent("send %s: %v", msg.Type, err))
+		if gs.ended.Load() { // 对局已经结束, 留在结束画面
+			gs.ShowNotice(T("notice.peer_left"))
+		} else {
+			gs.Quit() // 触发游戏结束流程
+		}
 	}
 	return err
 }
 
+// 本地玩家的编号确定, 对局开始: 记下开始时间, 输出事件, 计入活跃对局 (需要在外部加锁调用)
+func (gs *GameState) startGameInternal() {
+	gs.started = time.Now()
+	gs.events.Emit(gs.startedEventInternal())
+	metricActiveGames.Inc()
+}
+
+// 通知所有 goroutine 退出 (/quit)
+func (gs *GameState) Quit() {
+	select {
+	case <-gs.quitChan:
+	default:
+		close(gs.quitChan)
+	}
+}
+
 // Goroutine: 接收网络消息并发送到 channel
 func (gs *GameState) networkReceiver() {
-	defer func() {
-		// 如果接收循环退出（例如连接断开），也通知主循环
-		log.Println("Network receiver exiting.")
-		select {
-		case <-gs.quitChan: // 检查是否已经关闭
-		default:
-			close(gs.quitChan)
-		}
-	}()
+	defer slog.Debug("Network receiver exiting")
 
 	if gs.conn == nil {
-		log.Println("Cannot receive messages: no connection")
+		slog.Error("Cannot receive messages: no connection")
+		gs.Quit()
 		return
 	}
 
 	for {
 		select {
 		case <-gs.quitChan: // 检查是否需要退出
-			log.Println("Network receiver received quit signal.")
+			slog.Debug("Network receiver received quit signal")
 			return
 		default:
 			// 继续尝试读取
 		}
 
 		var msg Message
-		err := gs.decoder.Decode(&msg)
+		err := gs.conn.Receive(&msg)
 		if err != nil {
 			// 区分 EOF 和其他错误
 			if err == io.EOF || strings.Contains(err.Error(), "use of closed network connection") {
-				log.Printf("Connection closed by peer or locally.")
+				gs.logger().Info("Connection closed by peer or locally")
 			} else {
-				log.Printf("Error receiving message: %v.", err)
+				gs.logger().Warn("Error receiving message", "err", err)
+				if isDecodeError(err) {
+					metricDecodeErrors.Inc()
+				}
 			}
-			// 不论什么错误，都通知退出
+			// 不论什么错误，都通知主循环; 排在已收到的消息之后, 主循环先处理完最后的状态
 			select {
+			case gs.networkMsgChan <- Message{Type: msgTypeClosed}:
 			case <-gs.quitChan:
-			default:
-				close(gs.quitChan)
 			}
 			return
 		}
-		// log.Printf("DEBUG: Received raw message: %+v\n", msg)
+		gs.logger().Debug("Received message", "msg", msg)
 
 		// 发送到 channel，让主循环处理
 		select {
 		case gs.networkMsgChan <- msg:
-			// log.Printf("DEBUG: Sent message to networkMsgChan: %+v\n", msg)
 		case <-gs.quitChan:
-			log.Println("Network receiver shutting down while sending to channel.")
+			slog.Debug("Network receiver shutting down while sending to channel")
 			return
 		}
 	}
@@ -286,7 +309,7 @@ func (gs *GameState) networkReceiver() {
 // Goroutine: 从标准输入读取并发送到 channel
 func (gs *GameState) inputReader() {
 	defer func() {
-		log.Println("Input reader exiting.")
+		slog.Debug("Input reader exiting")
 		// 如果输入退出（例如Ctrl+D），也通知主循环
 		select {
 		case <-gs.quitChan:
@@ -294,23 +317,22 @@ func (gs *GameState) inputReader() {
 			close(gs.quitChan)
 		}
 	}()
-	reader := bufio.NewReader(os.Stdin)
+	reader := stdinReader
 	for {
 		select {
 		case <-gs.quitChan: // 检查是否需要退出
-			log.Println("Input reader received quit signal.")
+			slog.Debug("Input reader received quit signal")
 			return
 		default:
 			// 继续尝试读取
 		}
 
-		// log.Println("DEBUG: Input reader waiting for input...")
 		input, err := reader.ReadString('\n')
 		if err != nil {
 			if err == io.EOF {
-				log.Println("Input stream closed (EOF).")
+				slog.Info("Input stream closed (EOF)")
 			} else {
-				log.Printf("Error reading input: %v", err)
+				slog.Error("Error reading input", "err", err)
 			}
 			// 通知退出
 			select {
@@ -320,13 +342,12 @@ func (gs *GameState) inputReader() {
 			}
 			return
 		}
-		// log.Printf("DEBUG: Read input: %s", input)
+		slog.Debug("Read input", "input", strings.TrimSpace(input))
 		// 发送到 channel
 		select {
 		case gs.inputChan <- strings.TrimSpace(input):
-			// log.Printf("DEBUG: Sent input to inputChan: %s", strings.TrimSpace(input))
 		case <-gs.quitChan:
-			log.Println("Input reader shutting down while sending to channel.")
+			slog.Debug("Input reader shutting down while sending to channel")
 			return
 		}
 	}
@@ -334,74 +355,113 @@ func (gs *GameState) inputReader() {
 
 // 处理网络消息 (在主循环中调用)
 func (gs *GameState) handleNetworkMessage(msg Message) {
-	// log.Printf("DEBUG: Handling network message: %+v\n", msg)
+	if msg.Type == msgTypeClosed {
+		gs.mu.Lock()
+		over := gs.gameOver
+		gs.mu.Unlock()
+		if !over {
+			metricDisconnects.Inc()
+		}
+		if over { // 对方在对局结束后离开, 本地仍然可以复盘
+			gs.AddSystemMessage(T("notice.peer_left"))
+			gs.SetNeedsRedraw()
+		} else {
+			gs.Quit()
+		}
+		return
+	}
 	var opponentMoved = false
 	var chatReceived = false
 	var stateChanged = false
-	var senderName = fmt.Sprintf("Player %d", msg.Player) // 默认显示对方编号
+	var stateToSend *Message // 服务器在对方移动后广播的权威状态
 
-	gs.mu.Lock()     //加锁保护状态修改
+	gs.mu.Lock() //加锁保护状态修改
+	// 连接已绑定身份, 对方发来的移动和聊天一律视为来自 peerID, 忽略其自称的 Player
+	if msg.Type == MsgTypeMove || msg.Type == MsgTypeChat {
+		msg.Player = gs.peerID
+	}
+	peerID, senderName := gs.peerID, gs.peerName
+	if senderName == "" {
+		senderName = T("player.name", msg.Player) // 默认显示对方编号
+	}
 	if gs.gameOver { // 如果游戏已经结束，不再处理大部分消息
 		gs.mu.Unlock()
 		if msg.Type == MsgTypeChat { // 但仍然可以接收聊天消息
-			gs.AddChatMessage(senderName, msg.Content)
-			chatReceived = true
+			chatReceived = gs.receiveChat(peerID, senderName, msg.Content)
 		} else {
-			log.Printf("INFO: Ignoring message type %s because game is over.", msg.Type)
+			gs.logger().Info("Ignoring message because the game is over", "type", msg.Type)
 		}
-		// return // 如果不处理聊天，可以直接返回
+		gs.mu.Lock() // 与下方统一的解锁配对
 	} else { // 游戏进行中
 		switch msg.Type {
 		case MsgTypeMove:
-			if msg.Player != gs.playerID && gs.currentPlayer == msg.Player {
-				valid := gs.placePieceInternal(msg.X, msg.Y, msg.Player)
-				if valid {
-					opponentMoved = true // 标记对方移动成功
-					// 检查对方是否获胜或平局
-					if checkWinLogic(gs.board, msg.Player) {
-						gs.winner = msg.Player
-						gs.gameOver = true
-						stateChanged = true
-					} else if checkDrawLogic(gs.board) {
-						gs.winner = 3 // 平局
-						gs.gameOver = true
-						stateChanged = true
-					} else {
-						gs.currentPlayer = gs.playerID // 轮到自己
-						stateChanged = true
+			// Play 负责校验回合和位置, 并判定对方是否获胜或平局
+			switch err := gs.Play(msg.Player, msg.X, msg.Y); err {
+			case nil:
+				opponentMoved = true // 标记对方移动成功
+				stateChanged = true
+				metricMoves.Inc()
+				gs.events.Emit(gs.moveEventInternal(msg.Player, msg.X, msg.Y))
+				if gs.isServer { // 客户端 (例如浏览器) 以服务器的判定为准
+					stateToSend = &Message{Type: MsgTypeState, Turn: gs.currentPlayer, Winner: gs.winner, Hash: gs.HashText()}
+					if gs.gameOver {
+						stateToSend.Turn = 0
 					}
-				} else {
-					log.Printf("Received invalid move from opponent: (%d, %d)", msg.X, msg.Y)
-					// 可以选择发送错误消息回去
-					gs.mu.Unlock() // 发送消息前解锁
-					gs.SendMessage(Message{Type: MsgTypeError, Content: "Received invalid move"})
-					gs.mu.Lock() // 重新锁定以便继续
+					gs.events.Emit(stateEvent(*stateToSend))
 				}
-			} else if msg.Player == gs.playerID {
-				// 忽略自己发送的移动回显
-			} else {
-				log.Printf("WARN: Received move from player %d, but current turn is %d", msg.Player, gs.currentPlayer)
+			case ErrInvalidMove:
+				gs.loggerInternal().Warn("Rejected invalid move from opponent", "x", msg.X, "y", msg.Y)
+				metricInvalidMoves.Inc()
+				gs.events.Emit(errorEvent("invalid move from player %d: (%d, %d)", msg.Player, msg.X, msg.Y))
+				// 可以选择发送错误消息回去
+				gs.mu.Unlock() // 发送消息前解锁
+				gs.SendMessage(Message{Type: MsgTypeError, Content: "Received invalid move"})
+				gs.mu.Lock() // 重新锁定以便继续
+			default:
+				gs.loggerInternal().Warn("Received move out of turn", "from", msg.Player, "turn", gs.currentPlayer)
+				metricInvalidMoves.Inc()
 			}
 		case MsgTypeChat:
 			if msg.Player != gs.playerID { // 只记录和显示对方的消息
-				gs.mu.Unlock() // AddChatMessage 有自己的锁
-				gs.AddChatMessage(senderName, msg.Content)
+				gs.mu.Unlock() // receiveChat 有自己的锁, 并且可能回复错误
+				chatReceived = gs.receiveChat(peerID, senderName, msg.Content)
 				gs.mu.Lock() // 重新锁定
-				chatReceived = true
 			}
 		case MsgTypeState:
+			if gs.isServer { // 服务器自己判定胜负, 不接受客户端声明的状态
+				gs.loggerInternal().Warn("Ignoring state message from client", "peer", gs.peerName)
+				break
+			}
 			gs.currentPlayer = msg.Turn
 			gs.winner = msg.Winner
 			gs.gameOver = (msg.Winner != 0)
 			stateChanged = true
+			gs.events.Emit(stateEvent(msg))
+			if msg.Hash != "" && msg.Hash != gs.HashText() { // 没有哈希时 (旧版本的服务器) 不核对
+				gs.loggerInternal().Warn("Board out of sync with the server", "hash", msg.Hash, "ours", gs.HashText())
+				gs.events.Emit(errorEvent("board out of sync with the server (hash %s, ours %s)", msg.Hash, gs.HashText()))
+				gs.ShowNotice(T("notice.desync"))
+			}
 			if gs.gameOver {
-				log.Println("INFO: Received game over state from remote.")
+				gs.loggerInternal().Info("Received game over state from remote", "winner", gs.winner)
 			}
 		case MsgTypeAssign:
-			if gs.playerID == 0 {
+			if gs.playerID == 0 && !gs.isServer {
 				gs.playerID = msg.Player
-				log.Printf("INFO: Assigned player ID: %d\n", gs.playerID)
+				gs.peerID = 3 - msg.Player
+				gs.peerName = sanitizeText(msg.User)
+				if msg.Game != "" {
+					gs.gameID = msg.Game
+				}
+				gs.hintsOK = msg.Hints && gs.allowHints
+				gs.loggerInternal().Info("Assigned player ID", "peer", gs.peerName)
+				joined := gs.userName
+				if joined == "" {
+					joined = T("player.name", gs.playerID)
+				}
+				gs.AddSystemMessage(T("chat.joined", joined))
 				stateChanged = true
+				gs.startGameInternal()
 				// 初始化回合
 				if gs.playerID == Player1 {
 					gs.currentPlayer = Player1
@@ -410,35 +470,44 @@ func (gs *GameState) handleNetworkMessage(msg Message) {
 				}
 			}
 		case MsgTypeError:
-			log.Printf("Received error from opponent: %s", msg.Content)
+			gs.loggerInternal().Warn("Received error from opponent", "content", msg.Content)
+			gs.events.Emit(errorEvent("remote: %s", msg.Content))
 			// 可能需要根据错误类型设置 gameOver
-			stateChanged = true // 至少日志变了，可能需要重绘
+			gs.ShowNotice(T("notice.remote_error", sanitizeText(msg.Content)))
+			stateChanged = true
 		case MsgTypeNotify:
 			// 可以用来处理一些不需要锁的操作或简单通知
-			log.Printf("INFO: Received notification: %s", msg.Content)
+			gs.loggerInternal().Info("Received notification", "content", msg.Content)
+			if msg.Content == notifyHintUsed {
+				gs.AddSystemMessage(T("chat.peer_hint", senderName))
+			}
 			stateChanged = true // 可能需要重绘以显示通知或日志
 		default:
-			log.Printf("Received unknown message type: %s", msg.Type)
+			gs.loggerInternal().Warn("Received unknown message type", "type", msg.Type)
 		}
 	}
 	gs.mu.Unlock() // 解锁
 
+	if stateToSend != nil {
+		gs.SendMessage(*stateToSend) // 同步发送, 确保在游戏结束关闭连接前送达
+	}
+
 	// 根据处理结果，决定是否需要重绘屏幕
 	if opponentMoved || chatReceived || stateChanged {
 		gs.SetNeedsRedraw()
 	}
-	if gs.gameOver {
-		// 游戏结束后，确保通知所有 goroutine 退出
-		select {
-		case <-gs.quitChan:
-		default:
-			close(gs.quitChan)
-		}
-	}
+	// 游戏结束后不退出, 双方仍然可以聊天, 直到一方 /quit 或断开
 }
 
 // 处理用户输入 (在主循环中调用)
 func (gs *GameState) handleUserInput(input string) {
+	gs.ShowNotice("") // 清除上一次的提示
+
+	if strings.HasPrefix(input, "/") { // 聊天和其他命令随时可用
+		gs.handleCommand(input)
+		return
+	}
+
 	gs.mu.Lock() // 需要读取 playerID 和 currentPlayer
 	myPlayerID := gs.playerID
 	myTurn := (myPlayerID != 0) && (gs.currentPlayer == myPlayerID) && !gs.gameOver
@@ -446,249 +515,538 @@ func (gs *GameState) handleUserInput(input string) {
 	gs.mu.Unlock()
 
 	if myPlayerID == 0 {
-		fmt.Println("Still waiting for player assignment. Input ignored.")
-		gs.SetNeedsRedraw() // Need to redraw to potentially clear the invalid input prompt
+		gs.ShowNotice(T("notice.waiting_assignment"))
 		return
 	}
 
 	if isGameOver {
-		fmt.Println("Game is over. Input ignored.")
-		gs.SetNeedsRedraw()
+		gs.ShowNotice(T("notice.game_over"))
 		return
 	}
 
 	if !myTurn {
-		fmt.Println("It's not your turn.")
-		gs.SetNeedsRedraw() // 可能需要重绘以清除输入提示
+		gs.ShowNotice(T("notice.not_your_turn"))
 		return
 	}
 
-	var messageToSend *Message = nil // 指针，以便知道是否需要发送
-	var localChatMsg string = ""     // 用于本地显示自己的聊天
+	// --- 处理移动输入 ---
+	x, y, err := ParseCoord(input)
+	if err != nil {
+		gs.ShowNotice(T("notice.bad_input", err))
+		return
+	}
 
-	if strings.HasPrefix(input, "/c ") {
-		// --- 处理聊天输入 ---
-		chatMsg := strings.TrimPrefix(input, "/c ")
-		if chatMsg != "" {
-			localChatMsg = chatMsg // 记录本地消息内容
-			messageToSend = &Message{
-				Type:    MsgTypeChat,
-				Player:  myPlayerID,
-				Content: chatMsg,
-			}
-		}
+	var playErr error
+	var win, draw bool
+	var nextPlayer int
+
+	gs.mu.Lock() // --- 开始临界区 ---
+	// Play 会再次检查回合, 防止状态变化
+	if playErr = gs.Play(myPlayerID, x, y); playErr == nil {
+		gs.events.Emit(gs.moveEventInternal(myPlayerID, x, y))
+		metricMoves.Inc()
+		win = gs.winner == myPlayerID
+		draw = gs.winner == Draw
+		nextPlayer = gs.currentPlayer
+	}
+	gs.mu.Unlock() // --- 结束临界区 ---
+
+	if playErr != nil {
+		// 坐标已经校验过范围, 这里只可能是该点已有棋子
+		gs.ShowNotice(T("notice.taken", gs.notation.Format(x, y)))
+		return
+	}
+
+	moveMsg := Message{Type: MsgTypeMove, Player: myPlayerID, X: x, Y: y}
+	gs.SetNeedsRedraw() // 自己移动了，需要重绘
+
+	// 如果游戏因这次移动而结束，也发送最终状态
+	if win || draw {
+		gs.logger().Info("Game over after my move", "winner", gs.winner)
+		stateMsg := Message{Type: MsgTypeState, Winner: gs.winner, Turn: 0, Hash: gs.HashText()}
+		gs.events.Emit(stateEvent(stateMsg))
+		go func() { // 异步发送, 保证结束状态在移动之后
+			gs.SendMessage(moveMsg)
+			gs.SendMessage(stateMsg)
+		}()
 	} else {
-		// --- 处理移动输入 ---
-		parts := strings.Split(input, ",")
-		if len(parts) == 2 {
-			xStr, yStr := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
-			x, errX := strconv.Atoi(xStr)
-			y, errY := strconv.Atoi(yStr)
-
-			if errX == nil && errY == nil {
-				var validMove, win, draw bool
-				var nextPlayer int
-
-				gs.mu.Lock()                                        // --- 开始临界区 ---
-				if gs.currentPlayer == myPlayerID && !gs.gameOver { // 再次检查，防止状态变化
-					validMove = gs.placePieceInternal(x, y, myPlayerID)
-					if validMove {
-						win = checkWinLogic(gs.board, myPlayerID)
-						draw = checkDrawLogic(gs.board)
-						if win {
-							gs.winner = myPlayerID
-							gs.gameOver = true
-						} else if draw {
-							gs.winner = 3
-							gs.gameOver = true
-						} else {
-							gs.currentPlayer = 3 - myPlayerID // 切换回合
-							nextPlayer = gs.currentPlayer
-						}
-					}
-				}
-				gs.mu.Unlock() // --- 结束临界区 ---
-
-				if validMove {
-					// 准备发送移动消息
-					messageToSend = &Message{
-						Type:   MsgTypeMove,
-						Player: myPlayerID,
-						X:      x,
-						Y:      y,
-					}
-					gs.SetNeedsRedraw() // 自己移动了，需要重绘
-
-					// 如果游戏因这次移动而结束，也发送最终状态
-					if win || draw {
-						log.Println("INFO: Game over after my move.")
-						go gs.SendMessage(Message{Type: MsgTypeState, Winner: gs.winner, Turn: 0}) // 异步发送结束状态
-						// 确保退出
-						select {
-						case <-gs.quitChan:
-						default:
-							close(gs.quitChan)
-						}
-					} else {
-						log.Printf("INFO: My move successful, next turn: Player %d\n", nextPlayer)
-					}
-				} else {
-					fmt.Println("Invalid move. Try again.")
-					gs.SetNeedsRedraw()
-				}
-			} else {
-				fmt.Println("Invalid input format. Use x,y for moves (e.g., 7,7).")
-				gs.SetNeedsRedraw()
-			}
-		} else {
-			fmt.Println("Invalid input format. Use x,y for moves or /c <message>.")
-			gs.SetNeedsRedraw()
+		go gs.SendMessage(moveMsg) // 异步发送，避免阻塞主循环
+		gs.logger().Debug("Played move", "x", x, "y", y, "next", nextPlayer)
+	}
+}
+
+// 对局结果的文字说明; myID 为本地玩家编号 (用于显示 "你赢了")
+func resultText(r Renderer, winner, myID int) string {
+	switch winner {
+	case Draw:
+		return T("result.draw")
+	case Player1, Player2:
+		if winner == myID {
+			return T("result.you_win")
 		}
+		return T("result.wins", winner, r.StoneName(winner))
 	}
+	return T("result.ended") // 可能因断线
+}
 
-	// 发送消息（如果需要） - 在锁外执行
-	if messageToSend != nil {
-		go gs.SendMessage(*messageToSend) // 异步发送，避免阻塞主循环
+// 逐行打印的界面: 清屏后打印棋盘, 聊天和提示 (用于非终端的输入输出或 --plain)
+func (gs *GameState) renderPlain() {
+	// 在绘制前获取最新状态 (避免在锁内绘制)
+	gs.mu.Lock()
+	myPlayerID := gs.playerID
+	currentTurnPlayer := gs.currentPlayer
+	isMyTurn := (currentTurnPlayer == myPlayerID) && !gs.gameOver
+	isGameOver := gs.gameOver
+	winner := gs.winner
+	gs.mu.Unlock()
+
+	// 清屏或滚动以显示最新状态
+	fmt.Print("\033[H\033[2J") // ANSI 清屏 - 可选
+
+	gs.DisplayBoard()
+	gs.mu.Lock()
+	moveCount, moveList := len(gs.moves), FormatMoveList(gs.moves, gs.notation)
+	gs.mu.Unlock()
+	if moveCount > 0 {
+		fmt.Printf("%s\n\n", Tn("plain.moves", moveCount, moveList))
+	}
+	gs.DisplayChat()
+	if notice := gs.Notice(); notice != "" {
+		fmt.Println(notice)
 	}
 
-	// 如果是本地聊天消息，添加到聊天记录 - 在锁外执行
-	if localChatMsg != "" {
-		gs.AddChatMessage(fmt.Sprintf("You (Player %d)", myPlayerID), localChatMsg)
-		gs.SetNeedsRedraw() // 需要重绘聊天区
+	if isGameOver {
+		fmt.Println(T("result.game_over"))
+		fmt.Println(resultText(gs.renderer, winner, myPlayerID))
+		fmt.Println(T("plain.exit_hint"))
+	} else if myPlayerID != 0 { // 确保已分配 ID
+		if isMyTurn {
+			fmt.Print(T("plain.prompt", myPlayerID, gs.notation.Format(BoardSize/2, BoardSize/2)))
+		} else {
+			fmt.Println(T("plain.waiting_move", currentTurnPlayer))
+		}
+	} else {
+		fmt.Println(T("plain.connecting"))
 	}
 }
 
 // --- 主程序逻辑 ---
 
 func main() {
+	if strings.HasPrefix(filepath.Base(os.Args[0]), "pbrain-") { // Gomocup 的引擎命名约定
+		runPiskvork(os.Stdin, os.Stdout)
+		return
+	}
+	if len(os.Args) > 1 { // 子命令
+		switch os.Args[1] {
+		case "piskvork":
+			runPiskvork(os.Stdin, os.Stdout)
+			return
+		case "analyze":
+			runAnalyze(os.Args[2:])
+			return
+		case "solve":
+			runSolve(os.Args[2:])
+			return
+		case "tournament":
+			runTournament(os.Args[2:])
+			return
+		case "book":
+			runBook(os.Args[2:])
+			return
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		case "review":
+			runReview(os.Args[2:])
+			return
+		}
+	}
+
 	listenAddr := flag.String("listen", "", "Address to listen on (e.g., :8080) to run as server")
-	connectAddr := flag.String("connect", "", "Address to connect to (e.g., localhost:8080) to run as client")
+	connectAddr := flag.String("connect", "", "Address to connect to (e.g., localhost:8080, or grpc://localhost:8083) to run as client")
+	grpcAddr := flag.String("grpc", "", "Address to serve the gRPC game service on (e.g., :8083), as server")
+	discover := flag.Bool("discover", false, "List games announced on the local network and choose one to join")
+	discoverTime := flag.Duration("discover-time", 3*time.Second, "How long --discover listens for announcements")
+	noAnnounce := flag.Bool("no-announce", false, "Server: do not announce the game on the local network")
+	plain := flag.Bool("plain", false, "Use the line-based interface instead of the full-screen terminal UI")
+	noMouse := flag.Bool("no-mouse", false, "Full-screen UI: do not capture the mouse (keeps the terminal's text selection)")
+	lang := flag.String("lang", "", "Interface language, e.g. en or zh-CN (default from $LC_ALL, $LC_MESSAGES or $LANG)")
+	theme := flag.String("theme", "ascii", "Board theme: "+strings.Join(ThemeNames(), ", "))
+	chatFilterFile := flag.String("chat-filter", "", "File with words to mask in incoming chat, one per line")
+	notationName := flag.String("notation", "algebraic", "Coordinates for labels, move lists and records: algebraic (h8, rows counted from the bottom) or index (row,column from 0); input accepts both")
+	recordFile := flag.String("record", "", "Write the game record (moves and result) to this file when the game ends")
+	metricsAddr := flag.String("metrics", "", "Address to serve Prometheus metrics on at /metrics (e.g., :9100)")
+	eventsDest := flag.String("events", "", "Write game events as newline-delimited JSON to this file, or to a socket: unix:/path or tcp:host:port")
+	confirmClick := flag.Bool("confirm-click", false, "Full-screen UI: first click selects a point, a second click on it places the stone")
+	apiAddr := flag.String("api", "", "Address to serve the HTTP/JSON game API on (e.g., :8082); runs alone or next to --listen/--ws")
+	wsAddr := flag.String("ws", "", "Address to serve the browser client and WebSocket endpoint on (e.g., :8081), as server")
+	userName := flag.String("user", "", "Username to log in with (client) or to show to the opponent (server)")
+	password := flag.String("password", "", "Password for login (default $TICTACTOE_PASSWORD)")
+	token := flag.String("token", "", "Pre-shared token for login (default $TICTACTOE_TOKEN)")
+	usersFile := flag.String("users", "", "Server: JSON file with hashed user credentials; without it any username is accepted")
+	addUser := flag.String("add-user", "", "Set the password for a user in the --users file (read from stdin) and exit")
+	addToken := flag.String("add-token", "", "Generate a pre-shared token for a user in the --users file and exit")
+	tokenTTL := flag.Duration("token-ttl", 0, "--add-token: let the token expire after this long (e.g. 720h); 0 means it never expires")
+	allowHints := flag.Bool("allow-hints", false, "Networked games: allow /hint for both players if the opponent passes --allow-hints too (hint use is announced in chat); always allowed with --vs")
+	vsEngine := flag.String("vs", "", "Play locally against an engine: builtin (or e.g. builtin:strength=1200 for a weaker one), or the command line of a Piskvork engine")
+	handicapSpec := flag.String("handicap", "", "--vs: give yourself a head start, e.g. stones=2 (pre-placed stones) and/or moves=1 (extra moves)")
+	engineSpec := flag.String("engine", "", "Let an engine make your moves: builtin, or the command line of a Piskvork engine")
+	engineTime := flag.Duration("engine-time", 5*time.Second, "Time limit per engine move")
+	threads := flag.Int("threads", runtime.GOMAXPROCS(0), "Search threads for the builtin engine (--vs, --engine)")
+	playAs := flag.Int("play-as", Player1, "Server or --vs: play as Player 1 (moves first) or Player 2")
+	logLevel := flag.String("log-level", "", "Log level: debug (includes every protocol message), info, warn or error (default warn on a terminal, info with --log-file)")
+	logFile := flag.String("log-file", "", "Append logs to this file instead of stderr, away from the game display")
+	var tlsOpts TLSOptions
+	flag.StringVar(&tlsOpts.CertFile, "tls-cert", "", "PEM certificate: server certificate, or client certificate for mutual auth")
+	flag.StringVar(&tlsOpts.KeyFile, "tls-key", "", "PEM private key for --tls-cert")
+	flag.StringVar(&tlsOpts.CAFile, "tls-ca", "", "PEM CA bundle: server requires client certificates signed by it; client verifies the server with it")
+	flag.BoolVar(&tlsOpts.SelfSigned, "tls-self-signed", false, "Server: use a temporary self-signed certificate and print its fingerprint")
+	flag.StringVar(&tlsOpts.Pin, "tls-pin", "", "Client: accept only a server certificate with this SHA-256 fingerprint")
+	flag.BoolVar(&tlsOpts.Insecure, "tls-insecure", false, "Client: use TLS without verifying the server (testing only)")
 	flag.Parse()
+	closeLog, err := setupLogging(*logLevel, *logFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeLog()
+	SetLanguage(*lang)
+	// 环境变量在解析后读取, 不作为参数的默认值, 以免 -h 和用法说明把密码打印出来
+	if *password == "" {
+		*password = os.Getenv("TICTACTOE_PASSWORD")
+	}
+	if *token == "" {
+		*token = os.Getenv("TICTACTOE_TOKEN")
+	}
+
+	if *addUser != "" || *addToken != "" {
+		if err := manageUsers(*usersFile, *addUser, *addToken, *tokenTTL); err != nil {
+			log.Fatalf("Failed to update users: %v", err)
+		}
+		return
+	}
+
+	renderer, err := NewRenderer(*theme)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	notation, err := ParseNotation(*notationName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *playAs != Player1 && *playAs != Player2 {
+		log.Fatalf("--play-as must be %d or %d", Player1, Player2)
+	}
+
+	var chatFilter *WordFilter
+	if *chatFilterFile != "" {
+		if chatFilter, err = LoadWordFilter(*chatFilterFile); err != nil {
+			log.Fatalf("Failed to load chat filter: %v", err)
+		}
+	}
 
 	gs := &GameState{
-		board:          NewBoard(BoardSize),
-		currentPlayer:  0, // 等待分配
-		winner:         0,
-		gameOver:       false,
+		Game: Game{
+			board:         NewBoard(BoardSize),
+			currentPlayer: 0, // 等待分配
+			winner:        0,
+			gameOver:      false,
+		},
 		playerID:       0,
-		chatHistory:    make([]string, 0),
 		needsRedraw:    true,                   // 初始需要绘制
 		inputChan:      make(chan string, 1),   // 带缓冲，避免输入时阻塞发送者
 		networkMsgChan: make(chan Message, 10), // 带缓冲，处理突发消息
 		quitChan:       make(chan struct{}),    // 用于关闭信号
+		userName:       *userName,
+		renderer:       renderer,
+		notation:       notation,
+		chatFilter:     chatFilter,
+		engineMoveNum:  -1,
+		allowHints:     *allowHints,
+		reviewPos:      -1,
+		gameID:         randomHex(4), // 客户端收到分配后换成服务器的编号
+	}
+	if *eventsDest != "" {
+		if gs.events, err = OpenEventLog(*eventsDest); err != nil {
+			log.Fatal(err)
+		}
+		defer gs.events.Close()
+	}
+	if *engineSpec != "" {
+		if gs.engine, err = NewEngine(*engineSpec, *engineTime, *threads); err != nil {
+			log.Fatalf("Failed to start engine: %v", err)
+		}
+		defer gs.engine.Close()
+	}
+
+	// --- 服务器端共用的用户存储和 TLS 配置 ---
+	hosting := *listenAddr != "" || *wsAddr != "" || *grpcAddr != "" || *apiAddr != ""
+	var store *UserStore
+	var tlsConfig *tls.Config
+	if hosting {
+		if *usersFile != "" {
+			store, err = LoadUserStore(*usersFile)
+			if err != nil {
+				log.Fatalf("Failed to load users: %v", err)
+			}
+		} else {
+			slog.Warn("No --users file given, accepting any username without a password")
+		}
+		if tlsOpts.Enabled() {
+			tlsConfig, err = ServerTLSConfig(tlsOpts)
+			if err != nil {
+				log.Fatalf("Failed to set up TLS: %v", err)
+			}
+			fmt.Println(T("main.tls_fingerprint"))
+			fmt.Println("  " + CertFingerprint(tlsConfig.Certificates[0].Certificate[0]))
+			if tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert {
+				fmt.Println(T("main.client_certs"))
+			}
+		}
+	}
+
+	// 在后台运行的监听服务 (WebSocket, HTTP API, gRPC, 指标) 停止时把错误送到这里, 由主流程先恢复终端再退出
+	serverErr := make(chan error, 4)
+	fatalServer := func(err error) {
+		if gs.tui != nil {
+			gs.tui.Close()
+		}
+		log.Fatal(err)
 	}
 
-	var listener net.Listener
-	var conn net.Conn
-	var err error
+	if *metricsAddr != "" {
+		go func() { serverErr <- serveMetrics(*metricsAddr) }()
+	}
+
+	if *apiAddr != "" {
+		hub := NewGameHub(store)
+		go func() { serverErr <- serveAPI(*apiAddr, tlsConfig, hub) }()
+		if *listenAddr == "" && *wsAddr == "" && *grpcAddr == "" && *connectAddr == "" {
+			log.Fatal(<-serverErr) // 只提供 API, 不进行终端对局
+		}
+	}
+
+	if *discover {
+		addr, err := chooseDiscoveredGame(*discoverTime)
+		if err != nil {
+			log.Fatalf("Discovery failed: %v", err)
+		}
+		*connectAddr = addr
+	}
 
 	// --- 设置网络连接 ---
+	if *handicapSpec != "" && *vsEngine == "" {
+		log.Fatal("--handicap is only available with --vs")
+	}
 	isServer := false
-	if *listenAddr != "" {
+	if *vsEngine != "" {
 		isServer = true
-		fmt.Println("Starting server on", *listenAddr)
-		listener, err = net.Listen("tcp", *listenAddr)
+		gs.playerID = *playAs // 客户端的编号由服务器分配
+		if gs.userName == "" {
+			gs.userName = T("player.name", gs.playerID)
+		}
+		handicap, err := ParseHandicap(*handicapSpec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		handicap.Player = gs.playerID // 让子总是给本地玩家
+		if err = gs.ApplyHandicap(handicap); err != nil {
+			log.Fatal(err)
+		}
+		engine, err := NewEngine(*vsEngine, *engineTime, *threads)
 		if err != nil {
-			log.Fatalf("Failed to listen: %v", err)
+			log.Fatalf("Failed to start engine: %v", err)
 		}
-		defer listener.Close()
-		fmt.Println("Waiting for opponent to connect...")
-		conn, err = listener.Accept()
+		gs.isServer = true
+		if gs.conn, err = newEngineTransport(engine, handicap); err != nil {
+			engine.Close()
+			log.Fatalf("Failed to start engine: %v", err)
+		}
+		req, err := readLogin(gs.conn, nil, 3-gs.playerID)
 		if err != nil {
-			log.Fatalf("Failed to accept connection: %v", err)
+			log.Fatalf("Engine login failed: %v", err)
+		}
+		gs.acceptLogin(req)
+		gs.hintsOK = true // 与引擎对弈不算正式对局, 总是可以 /hint
+		gs.AddSystemMessage(T("chat.joined", gs.peerName))
+		if handicap.Stones > 0 || handicap.Moves > 0 {
+			gs.AddSystemMessage(T("chat.handicap", handicap.Stones, handicap.Moves))
+		}
+	} else if *listenAddr != "" || *wsAddr != "" || *grpcAddr != "" {
+		isServer = true
+		gs.playerID = *playAs
+		if gs.userName == "" {
+			gs.userName = T("player.name", gs.playerID)
+		}
+		// TCP 和 WebSocket 的新连接都汇入 incoming, 第一个登录成功的成为对手
+		incoming := make(chan Transport)
+		if *listenAddr != "" {
+			fmt.Println(T("main.starting_server", *listenAddr))
+			listener, err := net.Listen("tcp", *listenAddr)
+			if err != nil {
+				log.Fatalf("Failed to listen: %v", err)
+			}
+			if tlsConfig != nil {
+				listener = tls.NewListener(listener, tlsConfig)
+			}
+			defer listener.Close()
+			go acceptTCP(listener, incoming)
+		}
+		if *wsAddr != "" {
+			scheme := "http"
+			if tlsConfig != nil {
+				scheme = "https"
+			}
+			fmt.Println(T("main.serving_web", scheme, *wsAddr))
+			go func() { serverErr <- serveWebSocket(*wsAddr, tlsConfig, incoming) }()
+		}
+		if *grpcAddr != "" {
+			fmt.Println(T("main.serving_grpc", *grpcAddr))
+			go func() { serverErr <- serveGRPC(*grpcAddr, tlsConfig, incoming) }()
 		}
-		fmt.Println("Opponent connected from", conn.RemoteAddr())
+
+		// 在局域网内广播, 供 --discover 的客户端发现
+		stopAnnounce := make(chan struct{})
+		if !*noAnnounce && (*listenAddr != "" || *grpcAddr != "") {
+			go announceGame(Announcement{
+				User:     gs.userName,
+				Rules:    defaultRulesLabel,
+				Port:     portOf(*listenAddr),
+				GRPCPort: portOf(*grpcAddr),
+				WSPort:   portOf(*wsAddr),
+				TLS:      tlsConfig != nil,
+				Auth:     store != nil,
+			}, stopAnnounce)
+		}
+
+		gs.isServer = true
+		gs.chatLimiter = newRateLimiter(chatRatePerSec, chatRateBurst)
+		fmt.Println(T("main.waiting_opponent"))
+		req, err := waitForLogin(incoming, serverErr, store, 3-gs.playerID)
+		if err != nil {
+			log.Fatal(err)
+		}
+		gs.acceptLogin(req)
+		close(stopAnnounce)
+		go rejectExtraConnections(incoming)
+		fmt.Println(T("main.opponent_connected", gs.peerName, gs.conn.RemoteAddr()))
+		gs.AddSystemMessage(T("chat.joined", gs.peerName))
 	} else if *connectAddr != "" {
-		fmt.Println("Connecting to server at", *connectAddr)
-		conn, err = net.DialTimeout("tcp", *connectAddr, 10*time.Second)
+		fmt.Println(T("main.connecting", *connectAddr))
+		addr, useGRPC := strings.CutPrefix(*connectAddr, "grpc://")
+		var clientTLS *tls.Config
+		if tlsOpts.Enabled() {
+			host, _, _ := net.SplitHostPort(addr)
+			clientTLS, err = ClientTLSConfig(tlsOpts, host)
+			if err != nil {
+				log.Fatalf("Failed to set up TLS: %v", err)
+			}
+		}
+		if useGRPC {
+			gs.conn, err = dialGRPC(addr, clientTLS)
+		} else if clientTLS != nil {
+			dialer := &net.Dialer{Timeout: 10 * time.Second}
+			var tlsConn *tls.Conn
+			if tlsConn, err = tls.DialWithDialer(dialer, "tcp", addr, clientTLS); err == nil {
+				gs.conn = NewJSONTransport(tlsConn)
+			}
+		} else {
+			var conn net.Conn
+			if conn, err = net.DialTimeout("tcp", addr, 10*time.Second); err == nil {
+				gs.conn = NewJSONTransport(conn)
+			}
+		}
 		if err != nil {
 			log.Fatalf("Failed to connect: %v", err)
 		}
-		fmt.Println("Connected to server.")
+		fmt.Println(T("main.connected"))
+		if err = gs.sendLogin(*password, *token); err != nil {
+			log.Fatalf("Failed to send login: %v", err)
+		}
 	} else {
-		fmt.Println("Please specify either --listen <addr> or --connect <addr>")
+		fmt.Println(T("main.usage"))
 		os.Exit(1)
 	}
-	fmt.Println("Connection established.")
-	gs.conn = conn // 保存连接
-	gs.encoder = json.NewEncoder(conn)
-	gs.decoder = json.NewDecoder(conn)
+	fmt.Println(T("main.established"))
 	defer gs.conn.Close() // 确保连接最终关闭
 
 	// 启动 I/O goroutines
 	go gs.networkReceiver()
-	go gs.inputReader()
+	if !*plain && term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd())) {
+		if gs.tui, err = NewTUI(gs.renderer, !*noMouse, *confirmClick); err != nil {
+			slog.Warn("Full-screen UI unavailable, falling back to plain output", "err", err)
+		}
+	}
+	if gs.tui != nil {
+		defer gs.tui.Close() // 确保异常退出时也恢复终端
+		go gs.tui.readLoop(gs)
+	} else {
+		go gs.inputReader()
+	}
 
 	// --- 初始化玩家 (服务器发送分配) ---
 	if isServer {
 		gs.mu.Lock()
-		gs.playerID = Player1
-		gs.currentPlayer = Player1 // 服务器先手
+		gs.currentPlayer = Player1 // 玩家1先手
+		gs.mu.Unlock()
+		if gs.playerID == Player1 {
+			fmt.Println(T("main.you_are_first", gs.renderer.StoneName(Player1)))
+		} else {
+			fmt.Println(T("main.you_are_second", gs.renderer.StoneName(Player2)))
+		}
+		gs.mu.Lock()
+		hints := gs.hintsOK
+		gs.mu.Unlock()
+		assignMsg := Message{Type: MsgTypeAssign, Player: 3 - gs.playerID, User: gs.userName, Game: gs.gameID, Hints: hints}
+		gs.SendMessage(assignMsg) // 同步发送, 保证分配先于第一手到达 (引擎可能立即落子)
+		gs.mu.Lock()
+		gs.startGameInternal()
 		gs.mu.Unlock()
-		fmt.Println("You are Player 1 (X). Your turn.")
-		assignMsg := Message{Type: MsgTypeAssign, Player: Player2}
-		go gs.SendMessage(assignMsg) // 异步发送分配消息
 		gs.SetNeedsRedraw()
 	} else {
-		fmt.Println("Waiting for player assignment from server...")
+		fmt.Println(T("main.waiting_assignment"))
 		// 等待 Assign 消息在主循环中处理
 	}
 
+	// 对局结束后在聊天区写下结果, 只写一次; 之后留在结束画面, 可以继续聊天或 /review, 直到 /quit
+	finishGame := func() {
+		gs.mu.Lock()
+		gameOver, winner, moveCount := gs.gameOver, gs.winner, len(gs.moves)
+		gs.mu.Unlock()
+		if !gameOver || gs.ended.Load() {
+			return
+		}
+		gs.ended.Store(true)
+		gs.events.Emit(Event{Type: EventGameOver, Winner: winner, MoveNum: moveCount})
+		countGameEnded(gs.started, true)
+		gs.AddSystemMessage(Tn("chat.game_over", moveCount, resultText(gs.renderer, winner, gs.playerID)))
+		gs.AddSystemMessage(T("chat.post_game"))
+		gs.SetNeedsRedraw()
+	}
+
 	// --- 主事件循环 ---
 	ticker := time.NewTicker(100 * time.Millisecond) // 定期检查重绘
 	defer ticker.Stop()
 
 	running := true
 	for running {
+		gs.maybeStartEngine()
+		finishGame()
 
 		// 检查是否需要重绘并执行
 		if gs.CheckAndResetRedraw() {
-			// 在绘制前获取最新状态 (避免在锁内绘制)
-			gs.mu.Lock()
-			myPlayerID := gs.playerID
-			currentTurnPlayer := gs.currentPlayer
-			isMyTurn := (currentTurnPlayer == myPlayerID) && !gs.gameOver
-			isGameOver := gs.gameOver
-			gs.mu.Unlock()
-
-			// 清屏或滚动以显示最新状态
-			fmt.Print("\033[H\033[2J") // ANSI 清屏 - 可选
-
-			gs.DisplayBoard()
-			gs.DisplayChat()
-
-			if isGameOver {
-				gs.mu.Lock()
-				winner := gs.winner
-				gs.mu.Unlock()
-				fmt.Println("--- GAME OVER ---")
-				switch winner {
-				case Player1:
-					fmt.Println("Player 1 (X) wins!")
-				case Player2:
-					fmt.Println("Player 2 (O) wins!")
-				case 3:
-					fmt.Println("It's a draw!")
-				default:
-					fmt.Println("Game ended.") // 可能因断线
-				}
-				fmt.Println("Press Ctrl+C or close the window to exit.")
-				// running = false // 可以直接在这里退出循环，或者等待 quitChan
-			} else if myPlayerID != 0 { // 确保已分配 ID
-				if isMyTurn {
-					fmt.Printf("Your turn (Player %d). Enter move (x,y) or chat (/c message): ", myPlayerID)
-				} else {
-					fmt.Printf("Waiting for Player %d's move...\n", currentTurnPlayer)
-				}
+			if gs.tui != nil {
+				gs.tui.Render(gs)
 			} else {
-				fmt.Println("Connecting and waiting for player assignment...")
+				gs.renderPlain()
 			}
 		}
 
 		// 使用 select 处理不同的事件源
 		select {
 		case input := <-gs.inputChan:
-			// log.Println("DEBUG: Main loop received input:", input)
 			if input != "" { // 忽略空输入
 				gs.handleUserInput(input)
 			} else {
@@ -696,21 +1054,59 @@ func main() {
 			}
 
 		case msg := <-gs.networkMsgChan:
-			// log.Println("DEBUG: Main loop received network message:", msg.Type)
 			gs.handleNetworkMessage(msg)
 
 		case <-ticker.C:
 			// 定期检查，主要是为了在没有其他事件时也能触发重绘检查
-			// log.Println("DEBUG: Tick.") // 非常频繁，调试时才打开
+			if gs.tui != nil && gs.tui.NeedsClockRedraw() {
+				gs.SetNeedsRedraw() // 刷新状态栏中的计时
+			}
 			continue // 继续循环以检查 needsRedraw
 
+		case err := <-serverErr:
+			fatalServer(err)
+
 		case <-gs.quitChan:
-			fmt.Println("\nReceived quit signal. Exiting main loop.")
+			if gs.tui == nil {
+				fmt.Println("\n" + T("main.quit"))
+			}
 			running = false // 退出循环
 		}
 	} // end main loop
 
-	fmt.Println("Shutting down.")
+	// 对局可能刚好在退出前结束; 没有结束 (例如断线) 时也记一个结束事件
+	finishGame()
+	if !gs.ended.Load() {
+		gs.mu.Lock()
+		gs.events.Emit(Event{Type: EventGameOver, MoveNum: len(gs.moves)})
+		if !gs.started.IsZero() {
+			countGameEnded(gs.started, false)
+		}
+		gs.mu.Unlock()
+	}
+
+	// 退出时保存记录, 包括赛后的聊天; 对局没有结束时也保存已有的落子
+	if *recordFile != "" {
+		if err := gs.SaveRecord(*recordFile); err != nil {
+			gs.logger().Warn("Failed to save game record", "err", err)
+		} else {
+			gs.logger().Info("Game record saved", "file", *recordFile)
+		}
+	}
+	gs.mu.Lock()
+	gs.reviewPos = -1 // 最后显示结束时的局面
+	gs.mu.Unlock()
+
+	if gs.tui != nil {
+		gs.tui.Render(gs)           // 显示最终局面
+		time.Sleep(2 * time.Second) // 在全屏界面中停留片刻, 让用户看到结束信息
+		gs.tui.Close()
+		gs.renderPlain() // 恢复终端后在普通屏幕上留下最终局面
+		fmt.Println("\n" + T("main.shutting_down"))
+		return
+	}
+
+	fmt.Println(T("main.shutting_down"))
 	// (连接已通过 defer 关闭)
 	// 等待用户查看最终信息
 	time.Sleep(2 * time.Second) // 短暂等待，让用户看到结束信息
@@ -0,0 +1,207 @@
+// metrics.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 服务器的运行指标 (--metrics), 以 Prometheus 文本格式在 /metrics 输出.
+// 只用到计数器, 仪表和直方图三种, 不引入客户端库; 每秒落子数等速率由 Prometheus 对计数器取 rate() 得到
+
+type Counter struct{ v atomic.Uint64 }
+
+func (c *Counter) Inc() { c.v.Add(1) }
+
+type Gauge struct{ v atomic.Int64 }
+
+func (g *Gauge) Inc() { g.v.Add(1) }
+func (g *Gauge) Dec() { g.v.Add(-1) }
+
+// 直方图: 各个上界 (升序) 的累计计数, 以及总和与总数
+type Histogram struct {
+	mu     sync.Mutex
+	bounds []float64
+	counts []uint64 // counts[i] 为不超过 bounds[i] 的观测数 (非累计, 输出时累加)
+	sum    float64
+	count  uint64
+}
+
+func NewHistogram(bounds ...float64) *Histogram {
+	return &Histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i, _ := slices.BinarySearch(h.bounds, v); i < len(h.bounds) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+// 一个指标名下的一组序列, 按标签区分 (没有标签时只有一个)
+type metricFamily struct {
+	name, help, kind string
+	label            string // 标签名, 为空时没有标签
+	mu               sync.Mutex
+	series           map[string]any // 标签值 -> *Counter, *Gauge 或 *Histogram
+}
+
+// 取得 (不存在时创建) 标签值对应的序列
+func (f *metricFamily) with(value string) any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m, ok := f.series[value]
+	if !ok {
+		switch f.kind {
+		case "counter":
+			m = &Counter{}
+		case "gauge":
+			m = &Gauge{}
+		}
+		f.series[value] = m
+	}
+	return m
+}
+
+type metricsRegistry struct {
+	families []*metricFamily
+}
+
+func (r *metricsRegistry) add(name, help, kind, label string) *metricFamily {
+	f := &metricFamily{name: name, help: help, kind: kind, label: label, series: make(map[string]any)}
+	r.families = append(r.families, f)
+	return f
+}
+
+func (r *metricsRegistry) counter(name, help string) *Counter {
+	return r.add(name, help, "counter", "").with("").(*Counter)
+}
+
+func (r *metricsRegistry) gauge(name, help string) *Gauge {
+	return r.add(name, help, "gauge", "").with("").(*Gauge)
+}
+
+func (r *metricsRegistry) histogram(name, help string, bounds ...float64) *Histogram {
+	h := NewHistogram(bounds...)
+	r.add(name, help, "histogram", "").series[""] = h
+	return h
+}
+
+// 文本格式 0.0.4: 每个指标先写 HELP 和 TYPE, 序列按标签值排序
+func (r *metricsRegistry) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	printf := func(format string, args ...any) error {
+		k, err := fmt.Fprintf(w, format, args...)
+		n += int64(k)
+		return err
+	}
+	for _, f := range r.families {
+		if err := printf("# HELP %s %s\n# TYPE %s %s\n", f.name, helpEscaper.Replace(f.help), f.name, f.kind); err != nil {
+			return n, err
+		}
+		f.mu.Lock()
+		values := make([]string, 0, len(f.series))
+		for v := range f.series {
+			values = append(values, v)
+		}
+		slices.Sort(values)
+		series := make([]any, len(values))
+		for i, v := range values {
+			series[i] = f.series[v]
+		}
+		f.mu.Unlock()
+		for i, m := range series {
+			labels := ""
+			if f.label != "" {
+				labels = fmt.Sprintf("{%s=\"%s\"}", f.label, labelEscaper.Replace(values[i]))
+			}
+			var err error
+			switch m := m.(type) {
+			case *Counter:
+				err = printf("%s%s %d\n", f.name, labels, m.v.Load())
+			case *Gauge:
+				err = printf("%s%s %d\n", f.name, labels, m.v.Load())
+			case *Histogram:
+				m.mu.Lock()
+				var cum uint64
+				for j, b := range m.bounds {
+					cum += m.counts[j]
+					if err = printf("%s_bucket{le=%q} %d\n", f.name, formatFloat(b), cum); err != nil {
+						break
+					}
+				}
+				if err == nil {
+					err = printf("%s_bucket{le=\"+Inf\"} %d\n%s_sum %s\n%s_count %d\n",
+						f.name, m.count, f.name, formatFloat(m.sum), f.name, m.count)
+				}
+				m.mu.Unlock()
+			}
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// 文本格式只认这几种转义 (strconv.Quote 的 \u 等转义采集端不认识)
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// --- 服务器的指标 ---
+
+var (
+	metrics = &metricsRegistry{}
+
+	// 按传输方式 (tcp, ws, grpc) 统计的新连接, 包括之后登录失败或被拒绝的
+	metricConnections    = metrics.add("tictactoe_connections_total", "Incoming connections by transport.", "counter", "transport")
+	metricLoginsRejected = metrics.counter("tictactoe_logins_rejected_total", "Logins rejected (bad credentials or protocol).")
+	metricActiveGames    = metrics.gauge("tictactoe_active_games", "Games in progress (terminal game and HTTP API games).")
+	metricMoves          = metrics.counter("tictactoe_moves_total", "Moves played; rate() gives moves per second.")
+	metricInvalidMoves   = metrics.counter("tictactoe_invalid_moves_total", "Moves rejected as illegal or out of turn.")
+	metricDecodeErrors   = metrics.counter("tictactoe_decode_errors_total", "Messages that could not be decoded.")
+	metricDisconnects    = metrics.counter("tictactoe_disconnects_total", "Connections lost before the game was over.")
+	metricGameDuration   = metrics.histogram("tictactoe_game_duration_seconds", "Duration of finished games.",
+		30, 60, 120, 300, 600, 1200, 1800, 3600, 7200)
+)
+
+func countConnection(transport string) {
+	metricConnections.with(transport).(*Counter).Inc()
+}
+
+// 记录一局结束: 活跃对局减一; 下完的对局 (finished) 时长计入直方图, 中途放弃或断线的不计
+func countGameEnded(started time.Time, finished bool) {
+	metricActiveGames.Dec()
+	if finished {
+		metricGameDuration.Observe(time.Since(started).Seconds())
+	}
+}
+
+// 在 addr 上用普通 HTTP 提供 /metrics; 指标不含对局内容, 通常只对内网的采集端开放.
+// 服务停止时返回原因
+func serveMetrics(addr string) error {
+	slog.Info("Metrics listening", "addr", addr)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		metrics.WriteTo(w)
+	})
+	return fmt.Errorf("metrics server stopped: %w", http.ListenAndServe(addr, mux))
+}
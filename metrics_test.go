@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Prometheus 文本格式: HELP/TYPE, 标签的转义, 累计的 _bucket, +Inf, _sum 和 _count
+func TestMetricsExposition(t *testing.T) {
+	r := &metricsRegistry{}
+	moves := r.counter("test_moves_total", "Moves played.\nOne per stone.")
+	conns := r.add("test_connections_total", `Connections by "transport".`, "counter", "transport")
+	games := r.gauge("test_active_games", "Games in progress.")
+	duration := r.histogram("test_duration_seconds", "Game duration.", 1, 5, 10)
+
+	moves.Inc()
+	moves.Inc()
+	conns.with("tcp").(*Counter).Inc()
+	conns.with("a\"b\\c\nd é").(*Counter).Inc()
+	games.Inc()
+	games.Inc()
+	games.Dec()
+	for _, v := range []float64{0.5, 1, 3, 12, 30} { // 等于上界的计入该桶; 超过最大上界的只在 +Inf 中
+		duration.Observe(v)
+	}
+
+	var b strings.Builder
+	n, err := r.WriteTo(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_moves_total Moves played.\nOne per stone.
+# TYPE test_moves_total counter
+test_moves_total 2
+# HELP test_connections_total Connections by "transport".
+# TYPE test_connections_total counter
+test_connections_total{transport="a\"b\\c\nd é"} 1
+test_connections_total{transport="tcp"} 1
+# HELP test_active_games Games in progress.
+# TYPE test_active_games gauge
+test_active_games 1
+# HELP test_duration_seconds Game duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{le="1"} 2
+test_duration_seconds_bucket{le="5"} 3
+test_duration_seconds_bucket{le="10"} 3
+test_duration_seconds_bucket{le="+Inf"} 5
+test_duration_seconds_sum 46.5
+test_duration_seconds_count 5
+`
+	if got := b.String(); got != want {
+		t.Errorf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+	if n != int64(b.Len()) {
+		t.Errorf("WriteTo returned %d bytes, wrote %d", n, b.Len())
+	}
+}
@@ -0,0 +1,219 @@
+// moderation.go
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxChatLength  = 500 // 单条聊天的最大长度 (字符数)
+	chatRateBurst  = 5   // 允许连续发送的条数
+	chatRatePerSec = 1.0 // 之后每秒恢复的条数
+)
+
+var (
+	ErrChatTooLong = fmt.Errorf("chat message too long (max %d characters)", maxChatLength)
+	ErrChatRate    = errors.New("too many chat messages, slow down")
+)
+
+// 令牌桶限速
+type rateLimiter struct {
+	mu     sync.Mutex
+	tokens float64
+	rate   float64 // 每秒恢复的令牌数
+	burst  float64
+	last   time.Time
+}
+
+func newRateLimiter(perSec float64, burst int) *rateLimiter {
+	return &rateLimiter{tokens: float64(burst), rate: perSec, burst: float64(burst), last: time.Now()}
+}
+
+// 取一个令牌, 没有时返回 false
+func (l *rateLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// 服务器对单条聊天的限制
+func checkChatLength(content string) error {
+	if utf8.RuneCountInString(content) > maxChatLength {
+		return ErrChatTooLong
+	}
+	return nil
+}
+
+// 去掉控制字符后再显示: ESC 等 C0/C1 控制符会被终端当作指令执行,
+// 双向文本控制符会让显示顺序与实际内容不一致; 换行和制表符换成空格
+func sanitizeText(s string) string {
+	s = strings.ToValidUTF8(s, "�")
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			b.WriteByte(' ')
+		case unicode.IsControl(r):
+		case r >= 0x202A && r <= 0x202E, r >= 0x2066 && r <= 0x2069:
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// 屏蔽词过滤 (--chat-filter), 匹配的词替换为同样长度的 *
+// 只含 ASCII 的词按整词匹配, 其他 (如中文) 按子串匹配; 都不区分大小写
+type WordFilter struct {
+	words [][]rune // 小写
+}
+
+// 读取屏蔽词文件: 每行一个词, # 开头的行是注释
+func LoadWordFilter(path string) (*WordFilter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	filter := &WordFilter{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		filter.words = append(filter.words, lowerRunes(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// 逐个字符转小写, 保证与原文的字符位置一一对应
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isASCIIWord(word []rune) bool {
+	for _, r := range word {
+		if r >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// 返回过滤后的文本; f 为 nil 时原样返回
+func (f *WordFilter) Apply(s string) string {
+	if f == nil || len(f.words) == 0 {
+		return s
+	}
+	runes := []rune(s)
+	lower := lowerRunes(s)
+	for _, word := range f.words {
+		wholeWord := isASCIIWord(word)
+		for i := 0; i+len(word) <= len(lower); i++ {
+			if !runesEqual(lower[i:i+len(word)], word) {
+				continue
+			}
+			end := i + len(word)
+			if wholeWord && ((i > 0 && isWordRune(lower[i-1])) || (end < len(lower) && isWordRune(lower[end]))) {
+				continue
+			}
+			for j := i; j < end; j++ {
+				runes[j] = '*'
+			}
+		}
+	}
+	return string(runes)
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// --- 接收聊天 ---
+
+// 处理对方发来的聊天 (不能在持有 gs.mu 时调用)
+// 服务器先检查长度和频率, 超出时回复错误并丢弃; 之后按 /mute, 控制字符和屏蔽词处理
+func (gs *GameState) receiveChat(player int, sender, content string) bool {
+	if gs.isServer {
+		err := checkChatLength(content)
+		if err == nil && gs.chatLimiter != nil && !gs.chatLimiter.Allow() {
+			err = ErrChatRate
+		}
+		if err != nil {
+			gs.logger().Warn("Dropped chat", "from", sender, "err", err)
+			gs.SendMessage(Message{Type: MsgTypeError, Content: err.Error()})
+			return false
+		}
+	}
+	if gs.isMuted(sender) {
+		return false
+	}
+	gs.AddChatMessage(player, sender, gs.chatFilter.Apply(sanitizeText(content)), false)
+	return true
+}
+
+func (gs *GameState) isMuted(name string) bool {
+	gs.chatMu.Lock()
+	defer gs.chatMu.Unlock()
+	return gs.muted[name]
+}
+
+// /mute 和 /unmute: 不带参数时针对当前对手
+func (gs *GameState) setMuted(name string, mute bool) {
+	if name == "" {
+		gs.mu.Lock()
+		name = gs.peerName
+		if name == "" {
+			name = T("player.name", gs.peerID)
+		}
+		gs.mu.Unlock()
+	}
+	gs.chatMu.Lock()
+	if gs.muted == nil {
+		gs.muted = make(map[string]bool)
+	}
+	if mute {
+		gs.muted[name] = true
+	} else {
+		delete(gs.muted, name)
+	}
+	gs.chatMu.Unlock()
+	if mute {
+		gs.AddSystemMessage(T("chat.muted", name))
+	} else {
+		gs.AddSystemMessage(T("chat.unmuted", name))
+	}
+	gs.SetNeedsRedraw()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"hello", "hello"},
+		{"你好, 世界", "你好, 世界"},
+		{"a\tb\nc\rd", "a b c d"},
+		{"\x1b[2Jcleared", "[2Jcleared"},   // ESC 被去掉, 剩下的只是普通文本
+		{"bell\x07\x00", "bell"},           // C0 控制符
+		{"c1\u009bx", "c1x"},               // C1 控制符
+		{"abc\u202edef\u202c", "abcdef"},   // 双向文本覆盖
+		{"\u2066iso\u2069late", "isolate"}, // 双向文本隔离
+		{"bad\xffutf8", "bad�utf8"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeText(tt.in); got != tt.want {
+			t.Errorf("sanitizeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWordFilterApply(t *testing.T) {
+	filter := &WordFilter{words: [][]rune{lowerRunes("darn"), lowerRunes("坏蛋")}}
+	tests := []struct {
+		in, want string
+	}{
+		{"darn it", "**** it"},
+		{"DaRn!", "****!"},
+		{"darned", "darned"}, // ASCII 词按整词匹配
+		{"xdarn darn", "xdarn ****"},
+		{"你这个坏蛋啊", "你这个**啊"}, // 中文按子串匹配
+		{"坏蛋darn", "**darn"}, // 前面是字母, darn 不算整词
+		{"no match", "no match"},
+	}
+	for _, tt := range tests {
+		if got := filter.Apply(tt.in); got != tt.want {
+			t.Errorf("Apply(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	var none *WordFilter
+	if got := none.Apply("darn"); got != "darn" {
+		t.Errorf("nil filter: Apply(%q) = %q, want unchanged", "darn", got)
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	l := newRateLimiter(1, 3)
+	for i := 0; i < 3; i++ {
+		if !l.Allow() {
+			t.Fatalf("Allow() #%d = false, want true within burst", i+1)
+		}
+	}
+	if l.Allow() {
+		t.Fatal("Allow() after burst = true, want false")
+	}
+
+	// 把上次取令牌的时间往前拨, 模拟过了 2 秒: 恢复 2 个令牌
+	l.last = l.last.Add(-2 * time.Second)
+	for i := 0; i < 2; i++ {
+		if !l.Allow() {
+			t.Fatalf("Allow() #%d after 2s = false, want true", i+1)
+		}
+	}
+	if l.Allow() {
+		t.Fatal("Allow() after refilled tokens = true, want false")
+	}
+
+	// 令牌数不超过 burst
+	l.last = l.last.Add(-time.Hour)
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if l.Allow() {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("after a long pause %d messages allowed, want burst 3", allowed)
+	}
+}
+
+func TestCheckChatLength(t *testing.T) {
+	if err := checkChatLength(strings.Repeat("棋", maxChatLength)); err != nil {
+		t.Errorf("checkChatLength(%d runes) = %v, want nil", maxChatLength, err)
+	}
+	if err := checkChatLength(strings.Repeat("a", maxChatLength+1)); err != ErrChatTooLong {
+		t.Errorf("checkChatLength(%d runes) = %v, want ErrChatTooLong", maxChatLength+1, err)
+	}
+}
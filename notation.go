@@ -0,0 +1,108 @@
+// notation.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 坐标记法 (--notation), 影响棋盘标签, 落子列表和导出的记录
+type Notation int
+
+const (
+	NotationAlgebraic Notation = iota // 列字母 + 行号 (h8), 行号自下而上从 1 开始, 与五子棋文献一致
+	NotationIndex                     // 行,列 下标 (7,7), 即 board[x][y]
+)
+
+func ParseNotation(s string) (Notation, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "algebraic":
+		return NotationAlgebraic, nil
+	case "index":
+		return NotationIndex, nil
+	}
+	return 0, fmt.Errorf("unknown notation %q (available: algebraic, index)", s)
+}
+
+func (n Notation) String() string {
+	if n == NotationIndex {
+		return "index"
+	}
+	return "algebraic"
+}
+
+// 第 y 列的标签
+func (n Notation) ColLabel(y int) string {
+	if n == NotationIndex {
+		return strconv.Itoa(y)
+	}
+	return string(rune('a' + y))
+}
+
+// 第 x 行的标签 (x = 0 是屏幕上最上面一行)
+func (n Notation) RowLabel(x int) string {
+	if n == NotationIndex {
+		return strconv.Itoa(x)
+	}
+	return strconv.Itoa(BoardSize - x)
+}
+
+// 一个交叉点的坐标文本
+func (n Notation) Format(x, y int) string {
+	if n == NotationIndex {
+		return fmt.Sprintf("%d,%d", x, y)
+	}
+	return n.ColLabel(y) + n.RowLabel(x)
+}
+
+// 解析用户输入的坐标; 两种记法都接受, 从写法上就能区分:
+// 含逗号的是 "行,列" 下标 (7,7), 以字母开头的是代数记法 (h8 或 H8)
+func ParseCoord(s string) (x, y int, err error) {
+	s = strings.TrimSpace(s)
+	last := string(rune('a' + BoardSize - 1))
+	if rowStr, colStr, ok := strings.Cut(s, ","); ok {
+		x, errX := strconv.Atoi(strings.TrimSpace(rowStr))
+		y, errY := strconv.Atoi(strings.TrimSpace(colStr))
+		if errX != nil || errY != nil {
+			return 0, 0, errors.New(T("coord.index_not_numbers", s))
+		}
+		if x < 0 || x >= BoardSize || y < 0 || y >= BoardSize {
+			return 0, 0, errors.New(T("coord.index_range", s, BoardSize-1))
+		}
+		return x, y, nil
+	}
+
+	if s == "" || !isASCIILetter(s[0]) {
+		return 0, 0, errors.New(T("coord.unknown_format", s))
+	}
+	col := strings.ToLower(s[:1])[0]
+	if col > last[0] {
+		return 0, 0, errors.New(T("coord.column_range", s, last))
+	}
+	row, err := strconv.Atoi(strings.TrimSpace(s[1:]))
+	if err != nil || row < 1 || row > BoardSize {
+		return 0, 0, errors.New(T("coord.row_range", s, BoardSize))
+	}
+	return BoardSize - row, int(col - 'a'), nil
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// 落子列表, 每个回合一组: "1. h8 i9 2. j10"
+func FormatMoveList(moves []Move, n Notation) string {
+	var b strings.Builder
+	for i, m := range moves {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if i%2 == 0 {
+			fmt.Fprintf(&b, "%d. ", i/2+1)
+		}
+		b.WriteString(n.Format(m.X, m.Y))
+	}
+	return b.String()
+}
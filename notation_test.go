@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+func TestParseCoord(t *testing.T) {
+	tests := []struct {
+		in     string
+		x, y   int
+		hasErr bool
+	}{
+		{"h8", 7, 7, false},
+		{"H8", 7, 7, false},
+		{"a1", 14, 0, false},
+		{"o15", 0, 14, false},
+		{" j 10 ", 5, 9, false},
+		{"7,7", 7, 7, false},
+		{"0, 14", 0, 14, false},
+		{"p1", 0, 0, true},  // 列超出 a..o
+		{"a0", 0, 0, true},  // 行从 1 开始
+		{"a16", 0, 0, true}, // 行超出
+		{"15,0", 0, 0, true},
+		{"-1,3", 0, 0, true},
+		{"x,y", 0, 0, true},
+		{"88", 0, 0, true},
+		{"", 0, 0, true},
+	}
+	for _, tt := range tests {
+		x, y, err := ParseCoord(tt.in)
+		if tt.hasErr {
+			if err == nil {
+				t.Errorf("ParseCoord(%q) = (%d, %d), want error", tt.in, x, y)
+			}
+			continue
+		}
+		if err != nil || x != tt.x || y != tt.y {
+			t.Errorf("ParseCoord(%q) = (%d, %d, %v), want (%d, %d)", tt.in, x, y, err, tt.x, tt.y)
+		}
+	}
+}
+
+// 每个交叉点用两种记法格式化后都能解析回原处
+func TestFormatParseRoundTrip(t *testing.T) {
+	for _, n := range []Notation{NotationAlgebraic, NotationIndex} {
+		for x := 0; x < BoardSize; x++ {
+			for y := 0; y < BoardSize; y++ {
+				text := n.Format(x, y)
+				gx, gy, err := ParseCoord(text)
+				if err != nil || gx != x || gy != y {
+					t.Fatalf("%v: ParseCoord(%q) = (%d, %d, %v), want (%d, %d)", n, text, gx, gy, err, x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestFormatMoveList(t *testing.T) {
+	moves := []Move{{Player1, 7, 7}, {Player2, 6, 8}, {Player1, 5, 9}}
+	tests := []struct {
+		n    Notation
+		want string
+	}{
+		{NotationAlgebraic, "1. h8 i9 2. j10"},
+		{NotationIndex, "1. 7,7 6,8 2. 5,9"},
+	}
+	for _, tt := range tests {
+		if got := FormatMoveList(moves, tt.n); got != tt.want {
+			t.Errorf("FormatMoveList(%v) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+// piskvork.go
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Piskvork (Gomocup) 引擎协议: 管理程序通过标准输入输出与引擎逐行对话,
+// 主要命令有 START, BEGIN, TURN, BOARD, INFO, END. 坐标为 "x,y",
+// x 是列, y 是行, 都从左上角的 0 开始 (即 board[y][x])
+
+const (
+	piskvorkGrace       = 2 * time.Second // 超出每手时限多久后放弃等待
+	piskvorkStartupWait = 10 * time.Second
+)
+
+var (
+	piskvorkCoordPattern = regexp.MustCompile(`^\s*(\d+)\s*,\s*(\d+)\s*$`)
+	piskvorkNamePattern  = regexp.MustCompile(`name="([^"]*)"`)
+)
+
+// 作为子进程运行的外部引擎
+type PiskvorkEngine struct {
+	name    string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan string // 引擎的输出, 进程退出时关闭
+	timeout time.Duration
+	known   []Move // 引擎已经知道的落子, 用于决定发 TURN 还是 BOARD
+	mu      sync.Mutex
+}
+
+// 启动引擎并完成握手 (START, INFO, ABOUT); timeout 是每手的时限, 0 表示不限
+func StartPiskvork(command string, timeout time.Duration) (*PiskvorkEngine, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("empty engine command")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = &logLineWriter{logger: slog.With("engine", args[0]), msg: "Engine stderr"} // 不直接写终端, 以免打乱对局画面
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start engine: %w", err)
+	}
+	e := &PiskvorkEngine{name: args[0], cmd: cmd, stdin: stdin, lines: make(chan string, 16), timeout: timeout}
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			e.lines <- strings.TrimSpace(scanner.Text())
+		}
+		close(e.lines)
+	}()
+
+	if err := e.handshake(); err != nil {
+		e.Close()
+		return nil, err
+	}
+	slog.Info("Started Piskvork engine", "engine", e.name, "command", command)
+	return e, nil
+}
+
+func (e *PiskvorkEngine) handshake() error {
+	if err := e.send(fmt.Sprintf("START %d", BoardSize)); err != nil {
+		return err
+	}
+	reply, err := e.readReply(piskvorkStartupWait)
+	if err != nil {
+		return err
+	}
+	if reply != "OK" {
+		return fmt.Errorf("engine refused START %d: %s", BoardSize, reply)
+	}
+	if e.timeout > 0 {
+		e.send(fmt.Sprintf("INFO timeout_turn %d", e.timeout.Milliseconds()))
+	}
+	e.send("INFO timeout_match 0")
+	e.send("INFO rule 0") // 五连或更长都算赢
+	e.send("ABOUT")
+	if about, err := e.readReply(piskvorkStartupWait); err == nil {
+		if m := piskvorkNamePattern.FindStringSubmatch(about); m != nil && m[1] != "" {
+			e.name = m[1]
+		}
+	}
+	return nil
+}
+
+func (e *PiskvorkEngine) Name() string { return e.name }
+
+func (e *PiskvorkEngine) send(line string) error {
+	_, err := io.WriteString(e.stdin, line+"\n")
+	return err
+}
+
+// 读取下一条回复, 跳过 MESSAGE 和 DEBUG 行; wait 为 0 表示一直等
+func (e *PiskvorkEngine) readReply(wait time.Duration) (string, error) {
+	var timer <-chan time.Time
+	if wait > 0 {
+		timer = time.After(wait)
+	}
+	for {
+		select {
+		case line, ok := <-e.lines:
+			if !ok {
+				return "", errors.New("engine exited")
+			}
+			switch {
+			case strings.HasPrefix(line, "MESSAGE"):
+				slog.Info("Engine message", "engine", e.name, "text", strings.TrimSpace(strings.TrimPrefix(line, "MESSAGE")))
+			case strings.HasPrefix(line, "DEBUG"), line == "":
+			case strings.HasPrefix(line, "ERROR"), strings.HasPrefix(line, "UNKNOWN"):
+				return "", fmt.Errorf("engine error: %s", line)
+			default:
+				return line, nil
+			}
+		case <-timer:
+			return "", errors.New("engine did not respond in time")
+		}
+	}
+}
+
+// 只多了对方一手时发 TURN, 开局发 BEGIN, 其他情况用 BOARD 发送完整局面
+func (e *PiskvorkEngine) NextMove(moves []Move, player int) (Move, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var err error
+	switch {
+	case len(moves) == 0:
+		err = e.send("BEGIN")
+	case len(moves) == len(e.known)+1 && movesHavePrefix(moves, e.known) && moves[len(moves)-1].Player != player:
+		last := moves[len(moves)-1]
+		err = e.send(fmt.Sprintf("TURN %d,%d", last.Y, last.X))
+	default:
+		lines := []string{"BOARD"}
+		for _, m := range moves {
+			field := 2
+			if m.Player == player {
+				field = 1 // 引擎自己的棋子
+			}
+			lines = append(lines, fmt.Sprintf("%d,%d,%d", m.Y, m.X, field))
+		}
+		err = e.send(strings.Join(append(lines, "DONE"), "\n"))
+	}
+	if err != nil {
+		return Move{}, err
+	}
+
+	wait := time.Duration(0)
+	if e.timeout > 0 {
+		wait = e.timeout + piskvorkGrace
+	}
+	reply, err := e.readReply(wait)
+	if err != nil {
+		return Move{}, err
+	}
+	m, err := parsePiskvorkCoord(reply)
+	if err != nil {
+		return Move{}, err
+	}
+	m.Player = player
+	e.known = append(append([]Move(nil), moves...), m)
+	return m, nil
+}
+
+func movesHavePrefix(moves, prefix []Move) bool {
+	if len(prefix) > len(moves) {
+		return false
+	}
+	for i := range prefix {
+		if moves[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// "x,y" 转成 board[X][Y] 的坐标 (X 为行)
+func parsePiskvorkCoord(s string) (Move, error) {
+	m := piskvorkCoordPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Move{}, fmt.Errorf("expected a move \"x,y\", got %q", s)
+	}
+	col, _ := strconv.Atoi(m[1])
+	row, _ := strconv.Atoi(m[2])
+	if row >= BoardSize || col >= BoardSize {
+		return Move{}, fmt.Errorf("move %q is off the board", s)
+	}
+	return Move{X: row, Y: col}, nil
+}
+
+// 发送 END, 等引擎退出, 超时后强制结束
+func (e *PiskvorkEngine) Close() error {
+	e.send("END")
+	e.stdin.Close()
+	done := make(chan error, 1)
+	go func() { done <- e.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		e.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+// --- 作为 Piskvork 引擎运行 (内置 AI) ---
+
+// tictactoe piskvork (或者把程序命名为 pbrain-xxx): 在标准输入输出上按 Piskvork 协议对弈,
+// 供 Gomocup 管理程序和其他 GUI 使用. 自己的棋子记为玩家1, 对方记为玩家2
+func runPiskvork(in io.Reader, out io.Writer) {
+	ai := NewAI()
+	board := NewBoard(BoardSize)
+	w := bufio.NewWriter(out)
+	reply := func(format string, args ...any) {
+		fmt.Fprintf(w, format+"\n", args...)
+		w.Flush()
+	}
+	play := func() {
+		res := ai.Search(board, Player1)
+		if res.Move.Player == 0 {
+			reply("ERROR board is full")
+			return
+		}
+		board[res.Move.X][res.Move.Y] = Player1
+		reply("%d,%d", res.Move.Y, res.Move.X)
+	}
+	// 对方或 BOARD 中的一子; 坐标不合法时回复 ERROR
+	place := func(arg string, player int) bool {
+		m, err := parsePiskvorkCoord(arg)
+		if err != nil || board[m.X][m.Y] != Empty {
+			reply("ERROR invalid move %s", arg)
+			return false
+		}
+		board[m.X][m.Y] = player
+		return true
+	}
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		arg = strings.TrimSpace(arg)
+		switch strings.ToUpper(cmd) {
+		case "":
+		case "START":
+			if n, err := strconv.Atoi(arg); err != nil || n != BoardSize {
+				reply("ERROR only %dx%d boards are supported", BoardSize, BoardSize)
+				continue
+			}
+			board = NewBoard(BoardSize)
+			reply("OK")
+		case "RESTART":
+			board = NewBoard(BoardSize)
+			reply("OK")
+		case "BEGIN":
+			play()
+		case "TURN":
+			if place(arg, Player2) {
+				play()
+			}
+		case "BOARD":
+			board = NewBoard(BoardSize)
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if strings.EqualFold(line, "DONE") {
+					break
+				}
+				parts := strings.Split(line, ",")
+				if len(parts) != 3 {
+					continue
+				}
+				player := Player2
+				if strings.TrimSpace(parts[2]) == "1" {
+					player = Player1
+				}
+				place(parts[0]+","+parts[1], player)
+			}
+			play()
+		case "TAKEBACK":
+			if m, err := parsePiskvorkCoord(arg); err == nil {
+				board[m.X][m.Y] = Empty
+			}
+			reply("OK")
+		case "INFO":
+			key, value, _ := strings.Cut(arg, " ")
+			switch strings.ToLower(key) {
+			case "timeout_turn":
+				if ms, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && ms > 0 {
+					ai.TimeLimit = time.Duration(ms) * time.Millisecond * 4 / 5 // 留出余量
+				}
+			}
+		case "ABOUT":
+			reply(`name="tictactoe", version="1.0", author="tictactoe authors", country="-"`)
+		case "END":
+			return
+		default:
+			reply("UNKNOWN %s", cmd)
+		}
+	}
+}
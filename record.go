@@ -0,0 +1,244 @@
+// record.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 导出的对局记录 (--record), 格式类似 PGN: 标签行加落子列表
+//
+//	[Event "Gomoku"]
+//	[Date "2026.10.18"]
+//	[Player1 "alice"]
+//	[Player2 "bob"]
+//	[Notation "algebraic"]
+//	[Result "1-0"]
+//
+//	; [20:15:03] move 0 <alice> good luck
+//	; [20:15:40] move 2 * bob thinks hard
+//
+//	1. h8 i9 2. i8 h9 ... 1-0
+type GameRecord struct {
+	Date     time.Time
+	Players  [3]string   // 下标 1, 2 对应 Player1, Player2
+	Winner   int         // 0: 未结束, 1: Player1, 2: Player2, 3: 平局
+	Moves    []Move      // 包括让子时预先摆好的棋子
+	Chat     []ChatEntry // 玩家的聊天和动作 (不含系统消息)
+	Handicap Handicap    // 让子; 写成 Handicap 标签, 预先摆好的棋子不写入落子列表
+}
+
+// PGN 风格的结果
+func resultTag(winner int) string {
+	switch winner {
+	case Player1:
+		return "1-0"
+	case Player2:
+		return "0-1"
+	case Draw:
+		return "1/2-1/2"
+	}
+	return "*" // 未结束 (例如断线)
+}
+
+func (r GameRecord) Write(w io.Writer, n Notation) error {
+	result := resultTag(r.Winner)
+	_, err := fmt.Fprintf(w, "[Event \"Gomoku\"]\n[Date \"%s\"]\n[Player1 %q]\n[Player2 %q]\n[Board \"%d\"]\n[Notation %q]\n[Result %q]\n",
+		r.Date.Format("2006.01.02"), r.Players[Player1], r.Players[Player2], BoardSize, n.String(), result)
+	if err != nil {
+		return err
+	}
+	if h := r.Handicap.String(); h != "" {
+		if _, err := fmt.Fprintf(w, "[Handicap %q]\n", h); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+	if len(r.Chat) > 0 {
+		for _, e := range r.Chat {
+			if _, err := fmt.Fprintln(w, e.RecordLine()); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	moves := FormatMoveList(r.Moves[min(r.Handicap.Stones, len(r.Moves)):], n)
+	if moves != "" {
+		moves += " "
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", moves, result)
+	return err
+}
+
+var (
+	tagPattern     = regexp.MustCompile(`^\[(\w+)\s+(".*")\]$`)
+	moveNumPattern = regexp.MustCompile(`^\d+\.$`)
+)
+
+// 读取 Write 写出的对局记录; 聊天注释被跳过. 落子按规则重放校验,
+// 坐标两种记法都接受, 与 Notation 标签无关
+func ReadRecord(r io.Reader) (GameRecord, error) {
+	var rec GameRecord
+	var movetext []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "["):
+			m := tagPattern.FindStringSubmatch(line)
+			if m == nil {
+				return rec, fmt.Errorf("invalid tag line %q", line)
+			}
+			value, err := strconv.Unquote(m[2])
+			if err != nil {
+				return rec, fmt.Errorf("invalid tag line %q", line)
+			}
+			switch m[1] {
+			case "Date":
+				rec.Date, _ = time.Parse("2006.01.02", value)
+			case "Player1":
+				rec.Players[Player1] = value
+			case "Player2":
+				rec.Players[Player2] = value
+			case "Handicap":
+				if rec.Handicap, err = ParseHandicap(value); err != nil {
+					return rec, err
+				}
+			}
+		default:
+			movetext = append(movetext, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return rec, err
+	}
+	g := NewGame()
+	if err := g.ApplyHandicap(rec.Handicap); err != nil {
+		return rec, err
+	}
+	winner, err := replayMoveText(g, strings.Join(movetext, " "))
+	if err != nil {
+		return rec, err
+	}
+	rec.Moves, rec.Winner = g.Moves(), winner
+	return rec, nil
+}
+
+// 解析落子列表 ("1. h8 i9 2. j10 ..."), 回合编号可以省略, 结尾可以有结果;
+// 双方从玩家1开始交替落子, 并按规则校验. winner 取自结果 (没有时为 0)
+func ParseMoveText(text string) (moves []Move, winner int, err error) {
+	g := NewGame()
+	if winner, err = replayMoveText(g, text); err != nil {
+		return nil, 0, err
+	}
+	return g.Moves(), winner, nil
+}
+
+// 在 g 上依次执行落子列表, 轮到谁由 g 决定 (让子时同一方可能连走)
+func replayMoveText(g *Game, text string) (winner int, err error) {
+	for _, tok := range strings.Fields(text) {
+		switch tok {
+		case "1-0":
+			winner = Player1
+			continue
+		case "0-1":
+			winner = Player2
+			continue
+		case "1/2-1/2":
+			winner = Draw
+			continue
+		case "*":
+			continue
+		}
+		if moveNumPattern.MatchString(tok) {
+			continue
+		}
+		x, y, err := ParseCoord(tok)
+		if err != nil {
+			return 0, fmt.Errorf("move %d: %v", len(g.moves)+1, err)
+		}
+		if err := g.Play(g.currentPlayer, x, y); err != nil {
+			return 0, fmt.Errorf("move %d (%s): %w", len(g.moves)+1, tok, err)
+		}
+	}
+	return winner, nil
+}
+
+// 重放记录的前 n 手 (包括预先摆好的棋子; n < 0 表示全部).
+// 记录不一定来自 ReadRecord, 所以仍按规则校验每一手
+func (r GameRecord) Replay(n int) (*Game, error) {
+	g := NewGame()
+	if err := g.ApplyHandicap(r.Handicap); err != nil {
+		return nil, err
+	}
+	for i, m := range r.Moves {
+		if n >= 0 && i >= n {
+			break
+		}
+		if i < r.Handicap.Stones {
+			continue // 让子时已经摆好
+		}
+		if err := g.Play(m.Player, m.X, m.Y); err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+	}
+	return g, nil
+}
+
+// 当前对局的记录 (需要在外部加锁调用)
+func (gs *GameState) recordInternal() GameRecord {
+	rec := GameRecord{Date: time.Now(), Winner: gs.winner, Moves: gs.Moves(), Handicap: gs.handicap}
+	if gs.playerID == Player1 || gs.playerID == Player2 {
+		rec.Players[gs.playerID] = gs.userName
+		rec.Players[3-gs.playerID] = gs.peerName
+	}
+	for _, p := range []int{Player1, Player2} {
+		if rec.Players[p] == "" {
+			rec.Players[p] = fmt.Sprintf("Player %d", p)
+		}
+	}
+	return rec
+}
+
+// 需要写入记录的聊天 (去掉系统消息)
+func recordChat(entries []ChatEntry) []ChatEntry {
+	var chat []ChatEntry
+	for _, e := range entries {
+		if e.Kind != ChatSystem {
+			chat = append(chat, e)
+		}
+	}
+	return chat
+}
+
+// 把对局记录写入文件
+func (gs *GameState) SaveRecord(path string) error {
+	gs.mu.Lock()
+	rec := gs.recordInternal()
+	gs.mu.Unlock()
+	rec.Chat = recordChat(gs.ChatEntries())
+	return writeRecordFile(path, rec, gs.notation)
+}
+
+func writeRecordFile(path string, rec GameRecord, n Notation) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := rec.Write(f, n); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
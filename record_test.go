@@ -0,0 +1,141 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordWrite(t *testing.T) {
+	rec := GameRecord{
+		Date:    time.Date(2026, 10, 18, 20, 15, 0, 0, time.UTC),
+		Players: [3]string{"", "alice", "bob"},
+		Winner:  Player1,
+		Moves:   []Move{{Player1, 7, 7}, {Player2, 6, 8}, {Player1, 7, 8}},
+	}
+	var b strings.Builder
+	if err := rec.Write(&b, NotationAlgebraic); err != nil {
+		t.Fatal(err)
+	}
+	want := `[Event "Gomoku"]
+[Date "2026.10.18"]
+[Player1 "alice"]
+[Player2 "bob"]
+[Board "15"]
+[Notation "algebraic"]
+[Result "1-0"]
+
+1. h8 i9 2. i8 1-0
+`
+	if got := b.String(); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestResultTag(t *testing.T) {
+	for winner, want := range map[int]string{0: "*", Player1: "1-0", Player2: "0-1", Draw: "1/2-1/2"} {
+		if got := resultTag(winner); got != want {
+			t.Errorf("resultTag(%d) = %q, want %q", winner, got, want)
+		}
+	}
+}
+
+// Write 写出的记录经 ReadRecord 读回后与原记录一致, 两种记法都一样
+func TestRecordRoundTrip(t *testing.T) {
+	rec := GameRecord{
+		Date:    time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+		Players: [3]string{"", "alice", "鲍勃 \"b\""},
+		Winner:  Player1,
+		Moves: []Move{
+			{Player1, 7, 7}, {Player2, 6, 8}, {Player1, 7, 8}, {Player2, 6, 9},
+			{Player1, 7, 9}, {Player2, 0, 0}, {Player1, 7, 10}, {Player2, 14, 14},
+			{Player1, 7, 11},
+		},
+		Chat: []ChatEntry{{Time: time.Date(2026, 10, 18, 20, 15, 3, 0, time.UTC), Player: Player1, Sender: "alice", Text: "good luck", MoveNum: 2}},
+	}
+	for _, n := range []Notation{NotationAlgebraic, NotationIndex} {
+		var b strings.Builder
+		if err := rec.Write(&b, n); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadRecord(strings.NewReader(b.String()))
+		if err != nil {
+			t.Fatalf("%v: ReadRecord: %v\n%s", n, err, b.String())
+		}
+		if !got.Date.Equal(rec.Date) || got.Players != rec.Players || got.Winner != rec.Winner {
+			t.Errorf("%v: ReadRecord tags = %v %q %d, want %v %q %d", n, got.Date, got.Players, got.Winner, rec.Date, rec.Players, rec.Winner)
+		}
+		if !reflect.DeepEqual(got.Moves, rec.Moves) {
+			t.Errorf("%v: ReadRecord moves = %v, want %v", n, got.Moves, rec.Moves)
+		}
+	}
+}
+
+func TestParseMoveText(t *testing.T) {
+	tests := []struct {
+		text   string
+		moves  int
+		winner int
+		hasErr bool
+	}{
+		{"", 0, 0, false},
+		{"*", 0, 0, false},
+		{"1. h8 i9 2. i8 *", 3, 0, false},
+		{"h8 i9 i8", 3, 0, false}, // 回合编号可以省略
+		{"1. 7,7 6,8 0-1", 2, Player2, false},
+		{"1. h8 h8", 0, 0, true},                                     // 落在已有棋子上
+		{"1. h8 z9", 0, 0, true},                                     // 坐标无效
+		{"1. a1 b1 2. a2 b2 3. a3 b3 4. a4 b4 5. a5 b5", 0, 0, true}, // 已经分出胜负后继续落子
+	}
+	for _, tt := range tests {
+		moves, winner, err := ParseMoveText(tt.text)
+		if tt.hasErr {
+			if err == nil {
+				t.Errorf("ParseMoveText(%q) succeeded, want error", tt.text)
+			}
+			continue
+		}
+		if err != nil || len(moves) != tt.moves || winner != tt.winner {
+			t.Errorf("ParseMoveText(%q) = %d moves, winner %d, %v; want %d moves, winner %d", tt.text, len(moves), winner, err, tt.moves, tt.winner)
+		}
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	h := Handicap{Player: Player2, Stones: 2}
+	stones := []Move{
+		{Player2, handicapPoints[0][0], handicapPoints[0][1]},
+		{Player2, handicapPoints[1][0], handicapPoints[1][1]},
+	}
+	tests := []struct {
+		name   string
+		rec    GameRecord
+		n      int
+		moves  int
+		hasErr bool
+	}{
+		{"whole game", GameRecord{Moves: []Move{{Player1, 7, 7}, {Player2, 6, 8}, {Player1, 7, 8}}}, -1, 3, false},
+		{"first moves", GameRecord{Moves: []Move{{Player1, 7, 7}, {Player2, 6, 8}, {Player1, 7, 8}}}, 2, 2, false},
+		{"handicap", GameRecord{Handicap: h, Moves: append(stones, Move{Player1, 0, 0})}, -1, 3, false},
+		{"out of turn", GameRecord{Moves: []Move{{Player1, 7, 7}, {Player1, 6, 8}}}, -1, 0, true},
+		{"occupied", GameRecord{Moves: []Move{{Player1, 7, 7}, {Player2, 7, 7}}}, -1, 0, true},
+		{"off the board", GameRecord{Moves: []Move{{Player1, BoardSize, 0}}}, -1, 0, true},
+		{"too many stones", GameRecord{Handicap: Handicap{Player: Player2, Stones: len(handicapPoints) + 1}}, -1, 0, true},
+		{"no handicap player", GameRecord{Handicap: Handicap{Stones: 1}}, -1, 0, true},
+	}
+	for _, tt := range tests {
+		g, err := tt.rec.Replay(tt.n)
+		if tt.hasErr {
+			if err == nil {
+				t.Errorf("%s: Replay succeeded, want error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Replay(%d): %v", tt.name, tt.n, err)
+		} else if len(g.Moves()) != tt.moves {
+			t.Errorf("%s: Replay(%d) = %v, want %d moves", tt.name, tt.n, g.Moves(), tt.moves)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+// renderer.go
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 绘制棋盘所需的全部数据 (快照), 渲染器不访问 GameState
+type BoardView struct {
+	Board    [][]int
+	LastMove *Move
+	Notation Notation // 坐标标签的记法
+	CursorX  int      // 键盘光标, -1 表示不显示
+	CursorY  int
+	HoverX   int // 鼠标悬停, -1 表示没有
+	HoverY   int
+	PendingX int // 等待再次点击确认的点, -1 表示没有
+	PendingY int
+	Marks    [][]rune // 空位上的分析标记 (/hint, analyze), 0 表示没有; 为 nil 时不显示
+}
+
+// 没有任何高亮的棋盘视图
+func NewBoardView(board [][]int, lastMove *Move) BoardView {
+	return BoardView{
+		Board: board, LastMove: lastMove,
+		CursorX: -1, CursorY: -1, HoverX: -1, HoverY: -1, PendingX: -1, PendingY: -1,
+	}
+}
+
+// 棋盘渲染器, 由 --theme 选择
+type Renderer interface {
+	// 棋盘的文本行 (可能含 ANSI 序列, 不含换行符)
+	RenderBoard(v BoardView) []string
+	// 玩家棋子的简短名称, 用于提示和状态栏
+	StoneName(player int) string
+	// 格子 (0,0) 在 RenderBoard 输出中的位置 (行下标, 列下标), 每格宽 3 列;
+	// ok 为 false 表示不是网格布局, 不能用鼠标定位
+	CellOrigin() (line, col int, ok bool)
+}
+
+// 可选的主题
+var themes = map[string]func() Renderer{
+	"ascii":   func() Renderer { return &gridRenderer{frame: true, glyphs: [3]string{".", "X", "O"}} },
+	"unicode": func() Renderer { return &gridRenderer{boxDrawing: true, glyphs: [3]string{"", "●", "○"}} },
+	"color256": func() Renderer {
+		return &gridRenderer{
+			boxDrawing: true,
+			glyphs:     [3]string{"", "●", "●"},
+			names:      [3]string{"", "Black", "White"},
+			boardStyle: "\033[48;5;180m\033[38;5;94m", // 木色棋盘, 棕色网格
+			stoneStyle: [3]string{"", "\033[38;5;16m", "\033[38;5;231m"},
+			markStyle:  "\033[1;38;5;160m",
+		}
+	},
+	"truecolor": func() Renderer {
+		return &gridRenderer{
+			boxDrawing: true,
+			glyphs:     [3]string{"", "●", "●"},
+			names:      [3]string{"", "Black", "White"},
+			boardStyle: "\033[48;2;220;179;92m\033[38;2;107;79;29m",
+			stoneStyle: [3]string{"", "\033[38;2;0;0;0m", "\033[38;2;255;255;255m"},
+			markStyle:  "\033[1;38;2;200;30;30m",
+		}
+	},
+	// 色盲友好: 蓝/橙 (Okabe-Ito 配色) 加上不同形状, 不依赖颜色区分
+	"colorblind": func() Renderer {
+		return &gridRenderer{
+			boxDrawing: true,
+			glyphs:     [3]string{"", "X", "O"},
+			boardStyle: "\033[48;5;236m\033[38;5;244m",
+			stoneStyle: [3]string{"", "\033[1;38;5;32m", "\033[1;38;5;214m"},
+			markStyle:  "\033[1;38;5;231m",
+		}
+	},
+	"screenreader": func() Renderer { return linearRenderer{} },
+}
+
+// 按名称创建渲染器
+func NewRenderer(theme string) (Renderer, error) {
+	newFn, ok := themes[theme]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q (available: %s)", theme, strings.Join(ThemeNames(), ", "))
+	}
+	return newFn(), nil
+}
+
+// 所有主题名称 (排序后)
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var ansiPattern = regexp.MustCompile("\033\\[[0-9;?<]*[A-Za-z]")
+
+// 去掉 ANSI 序列后的显示宽度
+func visibleWidth(s string) int {
+	return stringWidth(ansiPattern.ReplaceAllString(s, ""))
+}
+
+// --- 网格渲染器 (ASCII, Unicode 和彩色主题) ---
+
+type gridRenderer struct {
+	frame      bool      // ASCII 边框 (+--+ 和 |)
+	boxDrawing bool      // 空位画成制表符交叉线, 棋子落在线上
+	glyphs     [3]string // 空位, 玩家1, 玩家2 的显示字符 (boxDrawing 时空位按位置绘制)
+	names      [3]string // 棋子名称, 为空时使用 glyphs
+	boardStyle string    // 棋盘区域的 SGR 序列, 空串表示不上色
+	stoneStyle [3]string // 棋子的 SGR 序列
+	markStyle  string    // 分析标记的 SGR 序列
+}
+
+func (r *gridRenderer) StoneName(player int) string {
+	if player < 1 || player > 2 {
+		return "?"
+	}
+	if r.names[player] != "" {
+		return r.names[player]
+	}
+	return r.glyphs[player]
+}
+
+func (r *gridRenderer) CellOrigin() (int, int, bool) {
+	if r.frame {
+		return 2, 3, true // 列号行和边框行之后
+	}
+	return 1, 3, true
+}
+
+// 制表符棋盘上 (i, j) 处的交叉线
+func boxIntersection(i, j int) string {
+	last := BoardSize - 1
+	switch {
+	case i == 0 && j == 0:
+		return "┌"
+	case i == 0 && j == last:
+		return "┐"
+	case i == 0:
+		return "┬"
+	case i == last && j == 0:
+		return "└"
+	case i == last && j == last:
+		return "┘"
+	case i == last:
+		return "┴"
+	case j == 0:
+		return "├"
+	case j == last:
+		return "┤"
+	}
+	return "┼"
+}
+
+// 加上 SGR 样式, 结束后恢复棋盘样式
+func (r *gridRenderer) styled(text, sgr string) string {
+	if sgr == "" {
+		return text
+	}
+	return sgr + text + ansiReset + r.boardStyle
+}
+
+// 一个格子, 固定 3 列宽
+func (r *gridRenderer) cell(v BoardView, i, j int) string {
+	p := v.Board[i][j]
+	left, right := " ", " "
+	glyph := r.glyphs[p]
+	if r.boxDrawing {
+		if j > 0 {
+			left = "─"
+		}
+		if j < BoardSize-1 {
+			right = "─"
+		}
+		if p == Empty {
+			glyph = boxIntersection(i, j)
+		}
+	}
+	sgr := r.stoneStyle[p]
+	if p == Empty && v.Marks != nil && v.Marks[i][j] != 0 {
+		glyph, sgr = string(v.Marks[i][j]), r.markStyle
+	}
+	if v.LastMove != nil && v.LastMove.X == i && v.LastMove.Y == j {
+		sgr += ansiBold // 高亮最后一手
+	}
+
+	switch {
+	case i == v.PendingX && j == v.PendingY:
+		return r.styled("?"+glyph+"?", ansiReverse+sgr) // 等待第二次点击确认
+	case i == v.CursorX && j == v.CursorY:
+		return r.styled("["+glyph+"]", ansiReverse+sgr)
+	case i == v.HoverX && j == v.HoverY:
+		return r.styled(left+glyph+right, ansiUnderline+sgr)
+	}
+	return left + r.styled(glyph, sgr) + right
+}
+
+func (r *gridRenderer) RenderBoard(v BoardView) []string {
+	var lines []string
+	header := "   " // 列标签
+	for j := 0; j < BoardSize; j++ {
+		header += fmt.Sprintf("%2s ", v.Notation.ColLabel(j))
+	}
+	border := "  +-" + strings.Repeat("--+", BoardSize)
+
+	lines = append(lines, header)
+	if r.frame {
+		lines = append(lines, border)
+	}
+	for i := 0; i < BoardSize; i++ {
+		var row strings.Builder
+		if r.frame {
+			fmt.Fprintf(&row, "%2s|", v.Notation.RowLabel(i)) // 行标签
+		} else {
+			fmt.Fprintf(&row, "%2s ", v.Notation.RowLabel(i))
+		}
+		row.WriteString(r.boardStyle)
+		for j := 0; j < BoardSize; j++ {
+			row.WriteString(r.cell(v, i, j))
+		}
+		if r.boardStyle != "" {
+			row.WriteString(ansiReset)
+		}
+		if r.frame {
+			fmt.Fprintf(&row, "|%s", v.Notation.RowLabel(i))
+		} else {
+			fmt.Fprintf(&row, " %s", v.Notation.RowLabel(i))
+		}
+		lines = append(lines, row.String())
+	}
+	if r.frame {
+		lines = append(lines, border)
+	}
+	lines = append(lines, header)
+	return lines
+}
+
+// --- 读屏友好的线性描述 ---
+
+// 不画网格, 而是用完整的句子列出双方棋子的位置, 方便读屏软件朗读
+type linearRenderer struct{}
+
+func (linearRenderer) StoneName(player int) string {
+	switch player {
+	case Player1:
+		return "X"
+	case Player2:
+		return "O"
+	}
+	return "?"
+}
+
+func (linearRenderer) CellOrigin() (int, int, bool) { return 0, 0, false }
+
+func (lr linearRenderer) RenderBoard(v BoardView) []string {
+	var stones [3][]string
+	count := 0
+	for i := range v.Board {
+		for j, p := range v.Board[i] {
+			if p != Empty {
+				stones[p] = append(stones[p], v.Notation.Format(i, j))
+				count++
+			}
+		}
+	}
+
+	layout := T("sr.layout_algebraic", v.Notation.ColLabel(BoardSize-1), BoardSize)
+	if v.Notation == NotationIndex {
+		layout = T("sr.layout_index")
+	}
+	lines := []string{Tn("sr.board", count, BoardSize, layout)}
+	if v.LastMove != nil {
+		lines = append(lines, T("sr.last_move", lr.StoneName(v.LastMove.Player), v.Notation.Format(v.LastMove.X, v.LastMove.Y)))
+	}
+	for _, p := range []int{Player1, Player2} {
+		if len(stones[p]) == 0 {
+			lines = append(lines, T("sr.stones_none", lr.StoneName(p)))
+		} else {
+			lines = append(lines, T("sr.stones", lr.StoneName(p), strings.Join(stones[p], "; ")))
+		}
+	}
+	var marks []string
+	for i := range v.Marks {
+		for j, m := range v.Marks[i] {
+			if m != 0 {
+				marks = append(marks, fmt.Sprintf("%s %c", v.Notation.Format(i, j), m))
+			}
+		}
+	}
+	if len(marks) > 0 {
+		lines = append(lines, T("sr.marks", strings.Join(marks, "; ")))
+	}
+	if v.CursorX >= 0 && v.CursorY >= 0 {
+		what := T("sr.empty")
+		if p := v.Board[v.CursorX][v.CursorY]; p != Empty {
+			what = lr.StoneName(p)
+		}
+		lines = append(lines, T("sr.cursor", v.Notation.Format(v.CursorX, v.CursorY), what))
+	}
+	return lines
+}
@@ -0,0 +1,452 @@
+// review.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 复盘: 用 AI 评估对局中的每一手, 标出失误和错过的胜机, 画出形势曲线
+
+const (
+	reviewDepth    = 4
+	reviewTimeout  = 300 * time.Millisecond // 每个局面的搜索时间
+	evalScale      = 400.0                  // 形势曲线的尺度: 评估值为 evalScale 时约为 0.76
+	mistakeLoss    = 300                    // 比最佳着法差这么多算失误
+	blunderLoss    = 1500                   // 比最佳着法差这么多算大错
+	graphHeight    = 7                      // 形势曲线的行数 (奇数, 中间一行是均势)
+	graphMaxWidth  = 60                     // 形势曲线最多的列数, 手数更多时合并
+	reviewMaxLines = 12                     // 聊天区最多列出的问题手
+)
+
+// 对一手棋的评价
+type Verdict int
+
+const (
+	VerdictGood      Verdict = iota
+	VerdictMistake           // 明显不如最佳着法
+	VerdictBlunder           // 差得很多, 或者让对方有了必胜
+	VerdictMissedWin         // 有必胜的走法却没有走
+)
+
+var verdictNames = map[Verdict]string{
+	VerdictMistake:   "review.mistake",
+	VerdictBlunder:   "review.blunder",
+	VerdictMissedWin: "review.missed_win",
+}
+
+type MoveReview struct {
+	Move      Move
+	Best      []Move  // 落子前局面的最佳变化, 第一手是推荐的着法
+	BestScore int     // 最佳着法的评估, 从落子方的角度
+	Score     int     // 实际着法的评估, 从落子方的角度
+	Eval      float64 // 落子后的形势, 从玩家1的角度, 在 -1 (玩家2必胜) 到 1 (玩家1必胜) 之间
+	Verdict   Verdict
+}
+
+// 一局的复盘; Moves[i] 是记录中的第 Start+i 手 (之前是让子时预先摆好的棋子)
+type Review struct {
+	Start int
+	Moves []MoveReview
+}
+
+// 复盘一局; 每个局面搜索一次, 落子后的评估就是下一个局面的评估.
+// progress 在每个局面搜索完后调用, 可以为 nil. 记录中有不合规则的落子时返回错误
+func ReviewGame(rec GameRecord, ai *AI, progress func(done, total int)) (*Review, error) {
+	g := NewGame()
+	if err := g.ApplyHandicap(rec.Handicap); err != nil {
+		return nil, err
+	}
+	start := min(rec.Handicap.Stones, len(rec.Moves))
+	moves := rec.Moves[start:]
+
+	type position struct {
+		res    SearchResult
+		toMove int
+		over   bool
+		winner int
+	}
+	positions := make([]position, len(moves)+1)
+	for k := range positions {
+		p := position{toMove: g.currentPlayer, over: g.gameOver, winner: g.winner}
+		if !g.gameOver {
+			p.res = ai.Search(g.board, g.currentPlayer)
+		}
+		positions[k] = p
+		if progress != nil {
+			progress(k+1, len(positions))
+		}
+		if k < len(moves) {
+			if err := g.Play(moves[k].Player, moves[k].X, moves[k].Y); err != nil {
+				return nil, fmt.Errorf("move %d: %w", start+k+1, err)
+			}
+		}
+	}
+
+	r := &Review{Start: start, Moves: make([]MoveReview, len(moves))}
+	for i, m := range moves {
+		before, after := positions[i], positions[i+1]
+		mr := MoveReview{Move: m, Best: before.res.PV, BestScore: before.res.Score}
+		switch {
+		case after.over && after.winner == m.Player:
+			mr.Score = scoreWin
+		case after.over:
+			mr.Score = 0 // 平局
+		case after.toMove == m.Player: // 让子时同一方连走
+			mr.Score = after.res.Score
+		default:
+			mr.Score = -after.res.Score
+		}
+		if m.Player == Player1 {
+			mr.Eval = evalToUnit(mr.Score)
+		} else {
+			mr.Eval = -evalToUnit(mr.Score)
+		}
+		mr.Verdict = judgeMove(mr, before.res.Move)
+		r.Moves[i] = mr
+	}
+	return r, nil
+}
+
+// 评估值换算到 [-1, 1]; 算出胜负时为 ±1
+func evalToUnit(score int) float64 {
+	switch {
+	case score >= scoreWin-scoreWinMargin:
+		return 1
+	case score <= -scoreWin+scoreWinMargin:
+		return -1
+	}
+	return math.Tanh(float64(score) / evalScale)
+}
+
+func judgeMove(mr MoveReview, best Move) Verdict {
+	if mr.Move.X == best.X && mr.Move.Y == best.Y {
+		return VerdictGood // 两次搜索的视野不同, 同一手的分值可能不一样
+	}
+	win := scoreWin - scoreWinMargin
+	loss := mr.BestScore - mr.Score
+	switch {
+	case mr.BestScore >= win && mr.Score < win:
+		return VerdictMissedWin
+	case mr.Score <= -win && mr.BestScore > -win, loss >= blunderLoss:
+		return VerdictBlunder
+	case loss >= mistakeLoss:
+		return VerdictMistake
+	}
+	return VerdictGood
+}
+
+// 各方的失误, 大错和错过胜机的次数, 下标为玩家编号
+func (r *Review) Counts() [3][4]int {
+	var counts [3][4]int
+	for _, mr := range r.Moves {
+		counts[mr.Move.Player][mr.Verdict]++
+	}
+	return counts
+}
+
+// 复盘的说明: 双方的统计, 形势曲线和问题手 (最多 reviewMaxLines 条)
+func (r *Review) Summary(rd Renderer, n Notation) []string {
+	lines := r.header(rd)
+	listed := 0
+	for i, mr := range r.Moves {
+		if mr.Verdict == VerdictGood {
+			continue
+		}
+		if listed == reviewMaxLines {
+			lines = append(lines, T("review.more"))
+			break
+		}
+		lines = append(lines, r.moveLine(r.Start+i+1, mr, rd, n))
+		listed++
+	}
+	return lines
+}
+
+// 双方的统计和形势曲线 (没有落子时没有曲线)
+func (r *Review) header(rd Renderer) []string {
+	counts := r.Counts()
+	var lines []string
+	for _, p := range []int{Player1, Player2} {
+		c := counts[p]
+		lines = append(lines, T("review.counts", rd.StoneName(p), c[VerdictMistake], c[VerdictBlunder], c[VerdictMissedWin]))
+	}
+	return append(lines, r.Graph(rd)...)
+}
+
+// 统计和曲线之后列出每一手 (review --all)
+func (r *Review) FullListing(rd Renderer, n Notation) []string {
+	lines := r.header(rd)
+	for i, mr := range r.Moves {
+		lines = append(lines, r.moveLine(r.Start+i+1, mr, rd, n)+"  "+T("review.eval", mr.Eval))
+	}
+	return lines
+}
+
+// "12. h8 (X): blunder, best i9 (i9 j10 k11)"
+func (r *Review) moveLine(num int, mr MoveReview, rd Renderer, n Notation) string {
+	text := fmt.Sprintf("%d. %s (%s)", num, n.Format(mr.Move.X, mr.Move.Y), rd.StoneName(mr.Move.Player))
+	if name, ok := verdictNames[mr.Verdict]; ok {
+		text += ": " + T(name)
+	}
+	if len(mr.Best) > 0 && (mr.Best[0].X != mr.Move.X || mr.Best[0].Y != mr.Move.Y) {
+		text += ", " + T("review.best", n.Format(mr.Best[0].X, mr.Best[0].Y), formatLine(mr.Best, n))
+	}
+	return text
+}
+
+// 形势曲线: 每列一手 (手数多时几手合并为一列, 取最后一手), 玩家1占优向上, 玩家2占优向下
+func (r *Review) Graph(rd Renderer) []string {
+	if len(r.Moves) == 0 {
+		return nil
+	}
+	cols := min(len(r.Moves), graphMaxWidth)
+	mid := graphHeight / 2
+	grid := make([][]byte, graphHeight)
+	for row := range grid {
+		fill := byte(' ')
+		if row == mid {
+			fill = '-'
+		}
+		grid[row] = []byte(strings.Repeat(string(fill), cols))
+	}
+	for c := 0; c < cols; c++ {
+		i := (c+1)*len(r.Moves)/cols - 1
+		target := mid - int(math.Round(r.Moves[i].Eval*float64(mid)))
+		for row := min(mid, target); row <= max(mid, target); row++ {
+			if row != mid || target == mid {
+				grid[row][c] = '#'
+			}
+		}
+	}
+	top, bottom := rd.StoneName(Player1), rd.StoneName(Player2)
+	width := max(stringWidth(top), stringWidth(bottom))
+	lines := make([]string, graphHeight)
+	for row := range grid {
+		label := ""
+		switch row {
+		case 0:
+			label = top
+		case graphHeight - 1:
+			label = bottom
+		}
+		lines[row] = label + strings.Repeat(" ", width-stringWidth(label)) + " |" + string(grid[row])
+	}
+	return lines
+}
+
+// --- 对局结束后的 /review ---
+
+// /review [next|prev|start|end|off|line|back|手数]: 第一次调用时在后台复盘, 之后在各个局面之间移动;
+// 棋盘显示所选局面, 并用数字标出该局面的最佳变化; line 和 back 在这个局面上逐手摆出或退回最佳变化
+func (gs *GameState) handleReview(arg string) {
+	gs.mu.Lock()
+	if !gs.gameOver {
+		gs.mu.Unlock()
+		gs.ShowNotice(T("notice.review_after_game"))
+		return
+	}
+	r, total := gs.review, len(gs.moves)
+	if r == nil {
+		if gs.reviewPos == -2 { // 已经在复盘
+			gs.mu.Unlock()
+			return
+		}
+		gs.reviewPos = -2
+		rec := gs.recordInternal()
+		gs.mu.Unlock()
+		go gs.runReview(rec)
+		return
+	}
+
+	pos := gs.reviewPos
+	if pos < 0 {
+		pos = total
+	}
+	switch strings.ToLower(arg) {
+	case "":
+		gs.mu.Unlock()
+		for _, line := range r.Summary(gs.renderer, gs.notation) {
+			gs.AddSystemMessage(line)
+		}
+		gs.SetNeedsRedraw()
+		return
+	case "next", "n":
+		pos++
+	case "prev", "p":
+		pos--
+	case "start":
+		pos = r.Start
+	case "end":
+		pos = total
+	case "off":
+		gs.reviewPos, gs.reviewLine = -1, 0
+		gs.mu.Unlock()
+		gs.SetNeedsRedraw()
+		return
+	case "line", "l":
+		gs.stepReviewLine(r, pos, true)
+		return
+	case "back", "b":
+		gs.stepReviewLine(r, pos, false)
+		return
+	default:
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			gs.mu.Unlock()
+			gs.ShowNotice(T("notice.usage_review"))
+			return
+		}
+		pos = n
+	}
+	pos = max(r.Start, min(pos, total))
+	gs.reviewPos, gs.reviewLine = pos, 0
+	gs.mu.Unlock()
+
+	// 说明落到这个局面的一手, 以及这个局面的最佳变化
+	if i := pos - r.Start - 1; i >= 0 {
+		gs.ShowNotice(r.moveLine(pos, r.Moves[i], gs.renderer, gs.notation) + "  " + T("review.eval", r.Moves[i].Eval))
+	} else {
+		gs.ShowNotice(T("review.at_start"))
+	}
+}
+
+// 在当前局面上摆出最佳变化的下一手 (forward) 或退回一手 (需要在外部加锁调用, 返回前解锁)
+func (gs *GameState) stepReviewLine(r *Review, pos int, forward bool) {
+	i := pos - r.Start
+	if i >= len(r.Moves) || len(r.Moves[i].Best) == 0 {
+		gs.mu.Unlock()
+		gs.ShowNotice(T("review.no_line"))
+		return
+	}
+	best := r.Moves[i].Best
+	if forward {
+		gs.reviewLine = min(gs.reviewLine+1, len(best))
+	} else {
+		gs.reviewLine = max(gs.reviewLine-1, 0)
+	}
+	gs.reviewPos = pos
+	k := gs.reviewLine
+	gs.mu.Unlock()
+	gs.ShowNotice(T("review.line_step", k, len(best), formatLine(best[:k], gs.notation)))
+}
+
+func (gs *GameState) runReview(rec GameRecord) {
+	ai := NewAI()
+	ai.Depth, ai.TimeLimit = reviewDepth, reviewTimeout
+	r, err := ReviewGame(rec, ai, func(done, total int) {
+		gs.ShowNotice(T("review.progress", done, total))
+	})
+	if err != nil {
+		gs.logger().Warn("Failed to review the game", "err", err)
+		gs.mu.Lock()
+		gs.reviewPos = -1 // 允许再次 /review
+		gs.mu.Unlock()
+		gs.ShowNotice(T("review.failed", err))
+		return
+	}
+	gs.mu.Lock()
+	gs.review, gs.reviewPos, gs.reviewLine = r, len(gs.moves), 0
+	gs.mu.Unlock()
+	for _, line := range r.Summary(gs.renderer, gs.notation) {
+		gs.AddSystemMessage(line)
+	}
+	gs.AddSystemMessage(T("review.usage"))
+	gs.ShowNotice("")
+}
+
+// 复盘时棋盘显示的局面和标记 (需要在外部加锁调用); 不在复盘时 ok 为 false
+func (gs *GameState) reviewViewInternal() (view BoardView, ok bool) {
+	if gs.review == nil || gs.reviewPos < 0 {
+		return view, false
+	}
+	g, err := GameRecord{Moves: gs.moves, Handicap: gs.handicap}.Replay(gs.reviewPos)
+	if err != nil { // 本局的落子都校验过, 不应该发生
+		gs.loggerInternal().Warn("Failed to replay the game for review", "err", err)
+		return view, false
+	}
+	var last *Move
+	if n := len(g.moves); n > 0 {
+		last = &g.moves[n-1]
+	}
+	var best []Move
+	if i := gs.reviewPos - gs.review.Start; i < len(gs.review.Moves) {
+		best = gs.review.Moves[i].Best
+	}
+	// 摆出的几手直接放到棋盘上 (不经过 Play, 让子时的轮次可能与 Best 不同);
+	// 标记只显示在空点上, 剩下的几手保留原来的编号
+	for i := range best[:min(gs.reviewLine, len(best))] {
+		g.board[best[i].X][best[i].Y] = best[i].Player
+		last = &best[i]
+	}
+	view = NewBoardView(g.board, last)
+	view.Notation = gs.notation
+	if best != nil {
+		view.Marks = lineMarks(best)
+	}
+	return view, true
+}
+
+// --- review 子命令 ---
+
+// tictactoe review [选项] 记录文件: 打印复盘的统计, 形势曲线和带评价的落子列表
+func runReview(args []string) {
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+	depth := fs.Int("depth", reviewDepth, "Search depth per position")
+	timeout := fs.Duration("time", reviewTimeout, "Time limit per position")
+	all := fs.Bool("all", false, "List every move, not only mistakes")
+	lang := fs.String("lang", "", "Interface language, e.g. en or zh-CN (default from $LC_ALL, $LC_MESSAGES or $LANG)")
+	theme := fs.String("theme", "ascii", "Board theme (for stone names): "+strings.Join(ThemeNames(), ", "))
+	notationName := fs.String("notation", "algebraic", "Coordinates: algebraic or index")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: tictactoe review [options] record-file | -")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	SetLanguage(*lang)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	renderer, err := NewRenderer(*theme)
+	if err != nil {
+		log.Fatal(err)
+	}
+	notation, err := ParseNotation(*notationName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var rec GameRecord
+	err = withInputFile(fs.Arg(0), func(r io.Reader) (err error) {
+		rec, err = ReadRecord(r)
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to load record: %v", err)
+	}
+
+	ai := NewAI()
+	ai.Depth, ai.TimeLimit = *depth, *timeout
+	r, err := ReviewGame(rec, ai, func(done, total int) {
+		fmt.Fprintf(os.Stderr, "\r%s", T("review.progress", done, total))
+	})
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		log.Fatalf("Failed to review the game: %v", err)
+	}
+
+	summary := r.Summary(renderer, notation)
+	if *all {
+		summary = r.FullListing(renderer, notation)
+	}
+	for _, line := range summary {
+		fmt.Println(line)
+	}
+}
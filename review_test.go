@@ -0,0 +1,184 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReviewGame(t *testing.T) {
+	ai := NewAI()
+	ai.Depth, ai.Width = 1, 4
+
+	rec := GameRecord{Moves: []Move{{Player1, 7, 7}, {Player2, 6, 8}, {Player1, 7, 8}}}
+	r, err := ReviewGame(rec, ai, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Start != 0 || len(r.Moves) != len(rec.Moves) {
+		t.Fatalf("ReviewGame = start %d, %d moves; want 0, %d", r.Start, len(r.Moves), len(rec.Moves))
+	}
+	for i, mr := range r.Moves {
+		if mr.Move != rec.Moves[i] || mr.Eval < -1 || mr.Eval > 1 {
+			t.Errorf("move %d: %+v", i+1, mr)
+		}
+	}
+
+	for _, bad := range []GameRecord{
+		{Moves: []Move{{Player1, 7, 7}, {Player2, 7, 7}}},
+		{Moves: []Move{{Player2, 7, 7}}},
+		{Handicap: Handicap{Player: Player2, Stones: len(handicapPoints) + 1}},
+	} {
+		if _, err := ReviewGame(bad, ai, nil); err == nil {
+			t.Errorf("ReviewGame(%+v) succeeded, want error", bad)
+		}
+	}
+}
+
+// 在固定的局面上检查对一手棋的评价: 错过成五, 放任对方的活三变成活四, 以及正常的防守
+func TestReviewVerdicts(t *testing.T) {
+	ai := NewAI()
+	ai.Depth, ai.Width = 3, 8
+
+	tests := []struct {
+		name  string
+		moves []Move
+		want  Verdict
+	}{
+		{
+			// X 的冲四 (h8-k8, 左端被 O 挡住) 只差 l8 成五, 却走了别处
+			name: "missed five",
+			moves: []Move{
+				{Player1, 7, 7}, {Player2, 7, 6}, {Player1, 7, 8}, {Player2, 0, 0},
+				{Player1, 7, 9}, {Player2, 0, 2}, {Player1, 7, 10}, {Player2, 0, 4},
+				{Player1, 12, 12},
+			},
+			want: VerdictMissedWin,
+		},
+		{
+			// X 有活三 h8-j8, O 不挡, X 下一手成活四
+			name: "open three left open",
+			moves: []Move{
+				{Player1, 7, 7}, {Player2, 0, 0}, {Player1, 7, 8}, {Player2, 0, 14},
+				{Player1, 7, 9}, {Player2, 14, 0},
+			},
+			want: VerdictBlunder,
+		},
+		{
+			name: "open three blocked",
+			moves: []Move{
+				{Player1, 7, 7}, {Player2, 0, 0}, {Player1, 7, 8}, {Player2, 0, 14},
+				{Player1, 7, 9}, {Player2, 7, 10},
+			},
+			want: VerdictGood,
+		},
+	}
+	for _, tt := range tests {
+		r, err := ReviewGame(GameRecord{Moves: tt.moves}, ai, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		last := r.Moves[len(r.Moves)-1]
+		if last.Verdict != tt.want {
+			t.Errorf("%s: verdict %v (score %d, best %d via %v), want %v",
+				tt.name, last.Verdict, last.Score, last.BestScore, last.Best, tt.want)
+		}
+	}
+}
+
+func TestJudgeMove(t *testing.T) {
+	played, other := Move{Player1, 7, 7}, Move{Player1, 8, 8}
+	win := scoreWin - 10
+	tests := []struct {
+		best, score int
+		bestMove    Move
+		want        Verdict
+	}{
+		{win, 100, other, VerdictMissedWin},
+		{win, win - 2, other, VerdictGood}, // 换一条路也能赢
+		{200, -win, other, VerdictBlunder}, // 让对方有了必胜
+		{200, 200 - blunderLoss, other, VerdictBlunder},
+		{200, 200 - mistakeLoss, other, VerdictMistake},
+		{200, 150, other, VerdictGood},
+		{win, -win, played, VerdictGood}, // 就是最佳着法, 两次搜索的分值不同也不算
+		{-win, -win, other, VerdictGood}, // 本来就输了
+	}
+	for _, tt := range tests {
+		mr := MoveReview{Move: played, BestScore: tt.best, Score: tt.score}
+		if got := judgeMove(mr, tt.bestMove); got != tt.want {
+			t.Errorf("judgeMove(best %d, score %d, best move %v) = %v, want %v", tt.best, tt.score, tt.bestMove, got, tt.want)
+		}
+	}
+}
+
+// /review line 和 back 在所选局面上逐手摆出最佳变化, 换局面时收起
+func TestReviewLineSteps(t *testing.T) {
+	moves := []Move{{Player1, 7, 7}, {Player2, 0, 0}}
+	best := []Move{{Player2, 6, 6}, {Player1, 8, 8}}
+	rd, err := NewRenderer("ascii")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := &GameState{Game: *gameWith(t, moves...), renderer: rd, reviewPos: 1}
+	gs.gameOver = true
+	gs.review = &Review{Moves: []MoveReview{{Move: moves[0]}, {Move: moves[1], Best: best}}}
+	check := func(arg string, wantLine int) {
+		t.Helper()
+		gs.handleReview(arg)
+		view, ok := gs.reviewViewInternal()
+		if !ok {
+			t.Fatalf("/review %s: not reviewing", arg)
+		}
+		if gs.reviewLine != wantLine {
+			t.Errorf("/review %s: line at %d, want %d", arg, gs.reviewLine, wantLine)
+		}
+		for k, m := range best {
+			want := Empty
+			if k < wantLine {
+				want = m.Player
+			}
+			if got := view.Board[m.X][m.Y]; got != want {
+				t.Errorf("/review %s: %v shows %d, want %d", arg, m, got, want)
+			}
+		}
+		if wantLine > 0 && *view.LastMove != best[wantLine-1] {
+			t.Errorf("/review %s: last move %v, want %v", arg, *view.LastMove, best[wantLine-1])
+		}
+	}
+	check("line", 1)
+	check("l", 2)
+	check("line", 2) // 已经摆完
+	check("back", 1)
+	check("b", 0)
+	check("b", 0)
+	check("line", 1)
+	check("end", 0)
+	// 终局之后没有最佳变化
+	gs.handleReview("line")
+	if gs.reviewLine != 0 || gs.Notice() != T("review.no_line") {
+		t.Errorf("/review line at the end: line %d, notice %q", gs.reviewLine, gs.Notice())
+	}
+}
+
+// 没有落子 (或只有让子) 的记录没有形势曲线, 列表仍然只有统计
+func TestReviewEmptyRecord(t *testing.T) {
+	rd, err := NewRenderer("ascii")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := ReadRecord(strings.NewReader("[Event \"Gomoku\"]\n\n*\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range []GameRecord{rec, {Handicap: Handicap{Player: Player2, Stones: 2}}} {
+		r, err := ReviewGame(rec, NewAI(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.FullListing(rd, NotationAlgebraic); len(got) != 2 {
+			t.Errorf("FullListing(%+v) = %q, want only the two count lines", rec, got)
+		}
+		if got := r.Summary(rd, NotationAlgebraic); len(got) != 2 {
+			t.Errorf("Summary(%+v) = %q, want only the two count lines", rec, got)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// solver.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// 威胁空间求解器: 在深度, 节点和时间限制内搜索 VCF / VCT 必胜序列
+
+// 求解的限制
+type SolveOptions struct {
+	VCT      bool          // VCF 没有解时再搜索 VCT
+	MaxDepth int           // 攻方最多走几手威胁 (不算最后成五的一手)
+	MaxNodes int           // 节点上限, 0 表示不限
+	Timeout  time.Duration // 时间上限, 0 表示不限
+}
+
+// 求解结果; Line 为 nil 表示在限制内没有找到
+type SolveResult struct {
+	Line     []Move // 攻守交替的必胜序列, 攻方先走
+	VCT      bool   // Line 中有活三, 否则是连续冲四
+	Depth    int    // 找到解时的搜索深度 (攻方成五之前的威胁手数)
+	Nodes    int
+	Elapsed  time.Duration
+	Complete bool // 在限制内搜完了全部深度 (没有解时说明确实没有 MaxDepth 以内的解)
+}
+
+// 为 attacker 搜索必胜序列, 不修改 board. 按深度迭代加深, 所以找到的是最短的解;
+// 先搜 VCF, 没有解时 (opts.VCT 为 true) 再搜 VCT
+func Solve(board [][]int, attacker int, opts SolveOptions) SolveResult {
+	start := time.Now()
+	s := &threatSearch{board: NewBoard(len(board)), attacker: attacker, maxNodes: opts.MaxNodes}
+	for i := range board {
+		copy(s.board[i], board[i])
+	}
+	if opts.Timeout > 0 {
+		s.deadline = start.Add(opts.Timeout)
+	}
+
+	var res SolveResult
+	for _, vct := range []bool{false, true} {
+		if vct && !opts.VCT {
+			break
+		}
+		s.vct = vct
+		for depth := 1; depth <= opts.MaxDepth && !s.stopped; depth++ {
+			if line := s.attack(depth); line != nil && !s.stopped {
+				res.Line, res.VCT, res.Depth = line, vct, depth
+				break
+			}
+		}
+		if res.Line != nil || s.stopped {
+			break
+		}
+	}
+	res.Nodes, res.Elapsed, res.Complete = s.nodes, time.Since(start), !s.stopped
+	return res
+}
+
+// --- 局面文件 ---
+
+// 棋盘图: 每行一条横线, 自上而下 (第 15 行在最上面), 用 . X O 表示空位和双方棋子;
+// 行号, 列字母行, 边框和空格会被忽略, 所以 analyze 打印的棋盘 (去掉标记后) 可以直接使用.
+// # 开头的行是注释. 不是棋盘图的文件按对局记录 (--record) 读取
+func LoadPosition(r io.Reader) ([][]int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var rows [][]int
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if row, ok := parseDiagramRow(line); ok {
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == 0 { // 不是棋盘图
+		rec, err := ReadRecord(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		g, err := rec.Replay(-1)
+		if err != nil {
+			return nil, err
+		}
+		rows = g.board
+	} else if len(rows) != BoardSize {
+		return nil, fmt.Errorf("board diagram has %d rows, want %d", len(rows), BoardSize)
+	}
+	// 棋盘图和对局记录一样: 已经分出胜负的局面没有可以求解的
+	for _, p := range []int{Player1, Player2} {
+		if checkWinLogic(rows, p) {
+			return nil, errors.New("the position already has five in a row")
+		}
+	}
+	return rows, nil
+}
+
+// 棋盘图的一行; 去掉行号, 边框和空格后应该正好是 BoardSize 个 . X O
+func parseDiagramRow(line string) ([]int, bool) {
+	var row []int
+	for _, c := range line {
+		switch c {
+		case '.', '+':
+			row = append(row, Empty)
+		case 'X', 'x':
+			row = append(row, Player1)
+		case 'O', 'o':
+			row = append(row, Player2)
+		case ' ', '\t', '|':
+		default:
+			if c < '0' || c > '9' {
+				return nil, false
+			}
+		}
+	}
+	return row, len(row) == BoardSize
+}
+
+// 没有指定时由棋子数推断行棋方: 数量相等时玩家1走, 玩家1多一子时玩家2走
+func sideToMove(board [][]int) (int, error) {
+	var count [3]int
+	for i := range board {
+		for _, p := range board[i] {
+			count[p]++
+		}
+	}
+	switch count[Player1] - count[Player2] {
+	case 0:
+		return Player1, nil
+	case 1:
+		return Player2, nil
+	}
+	return 0, fmt.Errorf("cannot tell whose move it is (%d X, %d O stones); use --side", count[Player1], count[Player2])
+}
+
+// --- solve 子命令 ---
+
+// tictactoe solve [选项] 局面文件: 打印行棋方的必胜序列; 在限制内没有找到时退出码为 1
+func runSolve(args []string) {
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	side := fs.String("side", "", "Side to move and win: X (Player 1) or O (Player 2); default from the stone count")
+	mode := fs.String("mode", "vct", "Search vcf (continuous fours only) or vct (VCF first, then threes as well)")
+	depth := fs.Int("depth", 10, "Maximum number of threats before the winning move")
+	timeout := fs.Duration("time", 30*time.Second, "Time budget (0 for none)")
+	lang := fs.String("lang", "", "Interface language, e.g. en or zh-CN (default from $LC_ALL, $LC_MESSAGES or $LANG)")
+	theme := fs.String("theme", "ascii", "Board theme: "+strings.Join(ThemeNames(), ", "))
+	notationName := fs.String("notation", "algebraic", "Coordinates for labels and the winning line: algebraic or index")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: tictactoe solve [options] position-file | -")
+		fmt.Fprintln(fs.Output(), "The file is a board diagram (rows of . X O, top row first) or a game record.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	SetLanguage(*lang)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	renderer, err := NewRenderer(*theme)
+	if err != nil {
+		log.Fatal(err)
+	}
+	notation, err := ParseNotation(*notationName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := SolveOptions{MaxDepth: *depth, Timeout: *timeout}
+	switch strings.ToLower(*mode) {
+	case "vcf":
+	case "vct":
+		opts.VCT = true
+	default:
+		log.Fatalf("unknown mode %q (available: vcf, vct)", *mode)
+	}
+
+	var board [][]int
+	err = withInputFile(fs.Arg(0), func(r io.Reader) (err error) {
+		board, err = LoadPosition(r)
+		return err
+	})
+	if err != nil {
+		log.Fatalf("Failed to load position: %v", err)
+	}
+	attacker := 0
+	switch strings.ToUpper(*side) {
+	case "":
+		if attacker, err = sideToMove(board); err != nil {
+			log.Fatal(err)
+		}
+	case "X", "1", "BLACK":
+		attacker = Player1
+	case "O", "2", "WHITE":
+		attacker = Player2
+	default:
+		log.Fatalf("unknown side %q (use X or O)", *side)
+	}
+
+	res := Solve(board, attacker, opts)
+	view := NewBoardView(board, nil)
+	view.Notation = notation
+	if res.Line != nil {
+		view.Marks = lineMarks(res.Line)
+	}
+	for _, line := range renderer.RenderBoard(view) {
+		fmt.Println(line)
+	}
+	fmt.Println()
+
+	stone := renderer.StoneName(attacker)
+	stats := T("solve.stats", res.Nodes, res.Elapsed.Round(time.Millisecond))
+	switch {
+	case res.Line == nil && res.Complete:
+		fmt.Println(T("solve.none", stone, *depth, stats))
+		os.Exit(1)
+	case res.Line == nil:
+		fmt.Println(T("solve.budget", stone, stats))
+		os.Exit(1)
+	case res.VCT:
+		fmt.Println(T("analysis.vct", stone, formatLine(res.Line, notation)))
+	default:
+		fmt.Println(T("analysis.vcf", stone, formatLine(res.Line, notation)))
+	}
+	fmt.Println(Tn("solve.found", (len(res.Line)+1)/2, stats))
+}
+
+// 按顺序给序列中的落子编号 (1-9, 之后用 a-z), 用于在棋盘上显示
+func lineMarks(line []Move) [][]rune {
+	const labels = "123456789abcdefghijklmnopqrstuvwxyz"
+	marks := make([][]rune, BoardSize)
+	for i := range marks {
+		marks[i] = make([]rune, BoardSize)
+	}
+	for i, m := range line {
+		if i < len(labels) {
+			marks[m.X][m.Y] = rune(labels[i])
+		}
+	}
+	return marks
+}
+
+// 打开文件 (或 "-" 表示标准输入) 交给 fn 读取
+func withInputFile(path string, fn func(io.Reader) error) error {
+	if path == "-" {
+		return fn(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return fn(f)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 按顺序在 board 上走完 line, 检查双方交替且最后一手成五
+func checkWinningLine(t *testing.T, board [][]int, attacker int, line []Move) {
+	t.Helper()
+	if len(line) == 0 || len(line)%2 == 0 {
+		t.Fatalf("winning line %v should end with the attacker's move", line)
+	}
+	for i, m := range line {
+		want := attacker
+		if i%2 == 1 {
+			want = 3 - attacker
+		}
+		if m.Player != want || board[m.X][m.Y] != Empty {
+			t.Fatalf("move %d (%v) of %v is out of turn or on an occupied point", i+1, m, line)
+		}
+		board[m.X][m.Y] = m.Player
+	}
+	if !checkWinLogic(board, attacker) {
+		t.Fatalf("winning line %v does not end in five", line)
+	}
+}
+
+func TestSolve(t *testing.T) {
+	// (7,6) 冲四后对方挡 (7,7), 再在 (6,5) 斜向成活四: 两手冲四取胜
+	vcf2 := []Move{
+		{Player2, 7, 2}, {Player1, 7, 3}, {Player1, 7, 4}, {Player1, 7, 5},
+		{Player1, 5, 4}, {Player1, 8, 7},
+		{Player2, 0, 0}, {Player2, 0, 14}, {Player2, 14, 0},
+	}
+	// 横向的眠三 (左边被挡) 加竖向的两子: 在 (7,6) 冲四同时成活三, 是冲四胜
+	fourThree := []Move{
+		{Player2, 7, 2}, {Player1, 7, 3}, {Player1, 7, 4}, {Player1, 7, 5},
+		{Player1, 5, 6}, {Player1, 6, 6},
+	}
+	// 两条被挡住一头的三: 在交叉点 (7,7) 同时成两个冲四
+	fours := []Move{
+		{Player2, 7, 3}, {Player1, 7, 4}, {Player1, 7, 5}, {Player1, 7, 6},
+		{Player2, 3, 7}, {Player1, 4, 7}, {Player1, 5, 7}, {Player1, 6, 7},
+	}
+	openFour := []Move{{Player1, 7, 5}, {Player1, 7, 6}, {Player1, 7, 7}, {Player1, 7, 8}}
+	doubleThree := []Move{{Player1, 7, 5}, {Player1, 7, 6}, {Player1, 5, 7}, {Player1, 6, 7}}
+	// 对方已经是活四, 攻方挡一边另一边也会成五
+	lost := append([]Move{{Player2, 3, 3}, {Player2, 3, 4}, {Player2, 3, 5}, {Player2, 3, 6}}, vcf2[1:4]...)
+	tests := []struct {
+		name     string
+		moves    []Move
+		opts     SolveOptions
+		depth    int // 0 表示没有解
+		vct      bool
+		complete bool
+		first    *Move // 必胜序列的第一手 (按搜索顺序), 为 nil 时不检查
+	}{
+		{"open four wins at once", openFour, SolveOptions{MaxDepth: 8}, 1, false, true, &Move{Player1, 7, 4}},
+		{"open three", openFour[:3], SolveOptions{MaxDepth: 8}, 1, false, true, &Move{Player1, 7, 4}},
+		{"four-three", fourThree, SolveOptions{MaxDepth: 8}, 2, false, true, &Move{Player1, 7, 6}},
+		{"double four", fours, SolveOptions{MaxDepth: 8}, 1, false, true, &Move{Player1, 7, 7}},
+		{"closed three only", fourThree[:4], SolveOptions{MaxDepth: 8}, 0, false, true, nil},
+		{"vcf in two", vcf2, SolveOptions{MaxDepth: 10}, 2, false, true, nil},
+		{"vcf depth too small", vcf2, SolveOptions{MaxDepth: 1}, 0, false, true, nil},
+		{"vcf node limit", vcf2, SolveOptions{MaxDepth: 10, MaxNodes: 1}, 0, false, false, nil},
+		{"double three without vct", doubleThree, SolveOptions{MaxDepth: 4}, 0, false, true, nil},
+		{"double three with vct", doubleThree, SolveOptions{VCT: true, MaxDepth: 4}, 2, true, true, &Move{Player1, 7, 7}},
+		{"opponent open four", lost, SolveOptions{VCT: true, MaxDepth: 4}, 0, false, true, nil},
+		{"empty board", nil, SolveOptions{VCT: true, MaxDepth: 2}, 0, false, true, nil},
+	}
+	for _, tt := range tests {
+		board := boardWith(tt.moves...)
+		before := boardWith(tt.moves...)
+		res := Solve(board, Player1, tt.opts)
+		if !reflect.DeepEqual(board, before) {
+			t.Fatalf("%s: Solve modified the board", tt.name)
+		}
+		if res.Complete != tt.complete {
+			t.Errorf("%s: Complete = %v, want %v", tt.name, res.Complete, tt.complete)
+		}
+		if tt.depth == 0 {
+			if res.Line != nil {
+				t.Errorf("%s: Solve = %v, want none", tt.name, res.Line)
+			}
+			continue
+		}
+		if res.Line == nil {
+			t.Errorf("%s: Solve found no win, want depth %d", tt.name, tt.depth)
+			continue
+		}
+		if res.Depth != tt.depth || res.VCT != tt.vct {
+			t.Errorf("%s: Solve depth %d, VCT %v; want depth %d, VCT %v (%v)", tt.name, res.Depth, res.VCT, tt.depth, tt.vct, res.Line)
+		}
+		if tt.first != nil && res.Line[0] != *tt.first {
+			t.Errorf("%s: Solve starts with %v, want %v", tt.name, res.Line[0], *tt.first)
+		}
+		checkWinningLine(t, board, Player1, res.Line)
+	}
+}
+
+func TestLoadPosition(t *testing.T) {
+	rows := make([]string, BoardSize)
+	for i := range rows {
+		rows[i] = strings.Repeat(". ", BoardSize)
+	}
+	rows[7] = ". . . . . . . X O . . . . . . "
+	var diagram strings.Builder
+	diagram.WriteString("# X to move\n   a b c d e f g h i j k l m n o\n")
+	for i, row := range rows {
+		diagram.WriteString(strings.TrimSpace(strconv.Itoa(BoardSize-i)+" "+row) + "\n")
+	}
+	board, err := LoadPosition(strings.NewReader(diagram.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(board, boardWith(Move{Player1, 7, 7}, Move{Player2, 7, 8})) {
+		t.Errorf("LoadPosition(diagram) = %v", board)
+	}
+
+	// 不是棋盘图时按对局记录读取
+	board, err = LoadPosition(strings.NewReader("1. h8 i8 2. a1 *\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(board, boardWith(Move{Player1, 7, 7}, Move{Player2, 7, 8}, Move{Player1, 14, 0})) {
+		t.Errorf("LoadPosition(record) = %v", board)
+	}
+
+	for _, bad := range []string{
+		strings.Join(rows[:10], "\n"),                                // 行数不够
+		strings.Repeat("X X X X X . . . . . . . . . .\n", BoardSize), // 已经成五
+		"1. a1 b1 2. a2 b2 3. a3 b3 4. a4 b4 5. a5 1-0\n",            // 记录的最后局面已经成五
+	} {
+		if _, err := LoadPosition(strings.NewReader(bad)); err == nil {
+			t.Errorf("LoadPosition(%.30q...) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestSideToMove(t *testing.T) {
+	tests := []struct {
+		moves  []Move
+		want   int
+		hasErr bool
+	}{
+		{nil, Player1, false},
+		{[]Move{{Player1, 7, 7}}, Player2, false},
+		{[]Move{{Player1, 7, 7}, {Player2, 7, 8}}, Player1, false},
+		{[]Move{{Player2, 7, 8}}, 0, true},
+		{[]Move{{Player1, 7, 7}, {Player1, 7, 8}}, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := sideToMove(boardWith(tt.moves...))
+		if (err != nil) != tt.hasErr || got != tt.want {
+			t.Errorf("sideToMove(%v) = %d, %v; want %d", tt.moves, got, err, tt.want)
+		}
+	}
+}
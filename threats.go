@@ -0,0 +1,336 @@
+// threats.go
+package main
+
+import "time"
+
+// 一步棋形成的威胁, 按强度递增
+type ThreatKind int
+
+const (
+	ThreatNone    ThreatKind = iota
+	ThreatThree              // 活三: 再下一手可以成活四
+	ThreatFour               // 冲四: 再下一手可以成五, 对方只有一个防点
+	ThreatWinning            // 活四, 双四, 四三或双三: 对方没有冲四反击时必胜
+	ThreatFive               // 成五
+)
+
+// 棋盘上的四个方向: 横, 竖, 两条斜线
+var lineDirs = [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}}
+
+const lineRadius = 4 // 与一步棋有关的五连都在它前后 4 格以内
+
+// 线上的点: 己方, 空, 或被挡住 (对方棋子或棋盘外)
+const (
+	cellEmpty int8 = iota
+	cellOwn
+	cellBlocked
+)
+
+type line [2*lineRadius + 1]int8
+
+// 以 (x, y) 为中心, 沿 (dx, dy) 取一条线, 按 player 的视角编码; 中心按己方处理
+func lineAround(board [][]int, x, y, dx, dy, player int) line {
+	var l line
+	for i := -lineRadius; i <= lineRadius; i++ {
+		cx, cy := x+i*dx, y+i*dy
+		switch {
+		case cx < 0 || cx >= len(board) || cy < 0 || cy >= len(board) || (board[cx][cy] != Empty && board[cx][cy] != player):
+			l[i+lineRadius] = cellBlocked
+		case board[cx][cy] == player:
+			l[i+lineRadius] = cellOwn
+		}
+	}
+	l[lineRadius] = cellOwn
+	return l
+}
+
+// 中心所在的连子是否达到五个
+func (l *line) five() bool {
+	run := 1
+	for i := lineRadius - 1; i >= 0 && l[i] == cellOwn; i-- {
+		run++
+	}
+	for i := lineRadius + 1; i < len(l) && l[i] == cellOwn; i++ {
+		run++
+	}
+	return run >= 5
+}
+
+// 线上再下一子就能与中心连成五的空位数
+func (l *line) winPoints() int {
+	n := 0
+	for i := range l {
+		if l[i] == cellEmpty {
+			l[i] = cellOwn
+			if l.five() {
+				n++
+			}
+			l[i] = cellEmpty
+		}
+	}
+	return n
+}
+
+// 中心这一子在这条线上形成的威胁
+func (l line) threat() ThreatKind {
+	if l.five() {
+		return ThreatFive
+	}
+	switch l.winPoints() {
+	case 0:
+	case 1:
+		return ThreatFour
+	default:
+		return ThreatWinning // 活四 (或同一条线上的双四)
+	}
+	for i := range l {
+		if l[i] == cellEmpty {
+			l[i] = cellOwn
+			open := l.winPoints() >= 2
+			l[i] = cellEmpty
+			if open {
+				return ThreatThree
+			}
+		}
+	}
+	return ThreatNone
+}
+
+// player 在空位 (x, y) 落子形成的威胁 (综合四个方向)
+func moveThreat(board [][]int, x, y, player int) ThreatKind {
+	fours, threes := 0, 0
+	for _, d := range lineDirs {
+		switch lineAround(board, x, y, d[0], d[1], player).threat() {
+		case ThreatFive:
+			return ThreatFive
+		case ThreatWinning:
+			return ThreatWinning
+		case ThreatFour:
+			fours++
+		case ThreatThree:
+			threes++
+		}
+	}
+	switch {
+	case fours >= 2, fours == 1 && threes >= 1, threes >= 2:
+		return ThreatWinning
+	case fours == 1:
+		return ThreatFour
+	case threes == 1:
+		return ThreatThree
+	}
+	return ThreatNone
+}
+
+// player 在空位 (x, y) 落子是否成五
+func makesFive(board [][]int, x, y, player int) bool {
+	for _, d := range lineDirs {
+		run := 1
+		for i := 1; i < 5; i++ {
+			cx, cy := x+i*d[0], y+i*d[1]
+			if cx < 0 || cx >= len(board) || cy < 0 || cy >= len(board) || board[cx][cy] != player {
+				break
+			}
+			run++
+		}
+		for i := 1; i < 5; i++ {
+			cx, cy := x-i*d[0], y-i*d[1]
+			if cx < 0 || cx >= len(board) || cy < 0 || cy >= len(board) || board[cx][cy] != player {
+				break
+			}
+			run++
+		}
+		if run >= 5 {
+			return true
+		}
+	}
+	return false
+}
+
+// player 下一手就能成五的所有空位
+func fivePoints(board [][]int, player int) []Move {
+	var points []Move
+	for i := range board {
+		for j := range board[i] {
+			if board[i][j] == Empty && makesFive(board, i, j, player) {
+				points = append(points, Move{Player: player, X: i, Y: j})
+			}
+		}
+	}
+	return points
+}
+
+// 经过 (x, y) 的四条线上, player 再下一手就能成五的空位 (用于刚落子之后)
+func fivePointsThrough(board [][]int, x, y, player int) []Move {
+	var points []Move
+	for _, d := range lineDirs {
+		for i := -lineRadius; i <= lineRadius; i++ {
+			cx, cy := x+i*d[0], y+i*d[1]
+			if i == 0 || cx < 0 || cx >= len(board) || cy < 0 || cy >= len(board) || board[cx][cy] != Empty {
+				continue
+			}
+			if makesFive(board, cx, cy, player) {
+				points = append(points, Move{Player: player, X: cx, Y: cy})
+			}
+		}
+	}
+	return points
+}
+
+// 与 player 的棋子在同一条线上且相距不超过 4 的空位: 只有这些点能形成威胁
+func threatCandidates(board [][]int, player int) []Move {
+	size := len(board)
+	seen := make([]bool, size*size)
+	var cands []Move
+	for i := range board {
+		for j := range board[i] {
+			if board[i][j] != player {
+				continue
+			}
+			for _, d := range lineDirs {
+				for k := -lineRadius; k <= lineRadius; k++ {
+					cx, cy := i+k*d[0], j+k*d[1]
+					if cx < 0 || cx >= size || cy < 0 || cy >= size || board[cx][cy] != Empty || seen[cx*size+cy] {
+						continue
+					}
+					seen[cx*size+cy] = true
+					cands = append(cands, Move{Player: player, X: cx, Y: cy})
+				}
+			}
+		}
+	}
+	return cands
+}
+
+// 一个威胁点: 在 (X, Y) 落子形成 Kind
+type ThreatPoint struct {
+	X, Y int
+	Kind ThreatKind
+}
+
+// player 下一手能形成 least 及以上威胁的点, 按威胁从强到弱排序
+func threatPoints(board [][]int, player int, least ThreatKind) []ThreatPoint {
+	var byKind [ThreatFive + 1][]ThreatPoint
+	for _, c := range threatCandidates(board, player) {
+		if k := moveThreat(board, c.X, c.Y, player); k >= least {
+			byKind[k] = append(byKind[k], ThreatPoint{X: c.X, Y: c.Y, Kind: k})
+		}
+	}
+	var points []ThreatPoint
+	for k := ThreatFive; k >= least; k-- {
+		points = append(points, byKind[k]...)
+	}
+	return points
+}
+
+// 同 threatPoints, 以落子的形式返回
+func threatMoves(board [][]int, player int, least ThreatKind) []Move {
+	var moves []Move
+	for _, p := range threatPoints(board, player, least) {
+		moves = append(moves, Move{Player: player, X: p.X, Y: p.Y})
+	}
+	return moves
+}
+
+// --- 连续冲四 (VCF) 和连续做威胁 (VCT) 搜索 ---
+
+// 攻方只走冲四 (VCF), 或者冲四和活三都可以 (VCT); 守方对冲四只能挡,
+// 对活三可以挡在任何相关的点上, 也可以用自己的冲四反击
+type threatSearch struct {
+	board    [][]int
+	attacker int
+	vct      bool
+	nodes    int
+	maxNodes int       // 0 表示不限
+	deadline time.Time // 零值表示不限时
+	stopped  bool      // 超出节点或时间限制, 结果作废
+}
+
+func (s *threatSearch) place(m Move) { s.board[m.X][m.Y] = m.Player }
+func (s *threatSearch) undo(m Move)  { s.board[m.X][m.Y] = Empty }
+
+// 攻方走: 找一手威胁, 使守方所有应手之后攻方仍然必胜
+func (s *threatSearch) attack(depth int) []Move {
+	a, d := s.attacker, 3-s.attacker
+	if wins := fivePoints(s.board, a); len(wins) > 0 {
+		return wins[:1]
+	}
+	if s.nodes++; s.maxNodes > 0 && s.nodes > s.maxNodes {
+		s.stopped = true
+	} else if !s.deadline.IsZero() && s.nodes%16 == 0 && time.Now().After(s.deadline) {
+		s.stopped = true
+	}
+	if s.stopped {
+		return nil
+	}
+	blocks := fivePoints(s.board, d)
+	switch {
+	case len(blocks) >= 2: // 对方有两个成五点, 挡不住
+		return nil
+	case len(blocks) == 1: // 必须先挡; 挡完如果攻方原有的威胁还在, 守方再应一次
+		block := Move{Player: a, X: blocks[0].X, Y: blocks[0].Y}
+		s.place(block)
+		line := s.defend(depth)
+		s.undo(block)
+		if line == nil {
+			return nil
+		}
+		return append([]Move{block}, line...)
+	case depth <= 0:
+		return nil
+	}
+
+	least := ThreatFour
+	if s.vct {
+		least = ThreatThree
+	}
+	for _, c := range threatMoves(s.board, a, least) {
+		s.place(c)
+		line := s.defend(depth - 1)
+		s.undo(c)
+		if line != nil {
+			return append([]Move{c}, line...)
+		}
+	}
+	return nil
+}
+
+// 守方走: 所有合理的应手都失败时返回攻方的胜利序列 (选最顽强的应手作为主线), 否则返回 nil
+func (s *threatSearch) defend(depth int) []Move {
+	a, d := s.attacker, 3-s.attacker
+	if len(fivePoints(s.board, d)) > 0 {
+		return nil // 守方直接成五
+	}
+	wins := fivePoints(s.board, a)
+	var replies []Move
+	switch {
+	case len(wins) >= 2: // 活四或双四
+		return []Move{{Player: d, X: wins[0].X, Y: wins[0].Y}, wins[1]}
+	case len(wins) == 1: // 冲四: 只能挡
+		replies = []Move{{Player: d, X: wins[0].X, Y: wins[0].Y}}
+	case !s.vct || len(threatMoves(s.board, a, ThreatWinning)) == 0:
+		return nil // 攻方没有威胁了, 守方可以随意走
+	default: // 活三: 挡在攻方能成四的点上, 或者冲四反击
+		for _, m := range threatMoves(s.board, a, ThreatFour) {
+			replies = append(replies, Move{Player: d, X: m.X, Y: m.Y})
+		}
+		replies = append(replies, threatMoves(s.board, d, ThreatFour)...)
+	}
+
+	var best []Move
+	for _, r := range replies {
+		if s.board[r.X][r.Y] != Empty {
+			continue // 同一点可能出现两次
+		}
+		s.place(r)
+		line := s.attack(depth)
+		s.undo(r)
+		if line == nil {
+			return nil
+		}
+		if len(line)+1 > len(best) {
+			best = append([]Move{r}, line...)
+		}
+	}
+	return best
+}
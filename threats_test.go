@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+// 按 (玩家, x, y) 摆出棋盘, 不检查轮次
+func boardWith(moves ...Move) [][]int {
+	board := NewBoard(BoardSize)
+	for _, m := range moves {
+		board[m.X][m.Y] = m.Player
+	}
+	return board
+}
+
+func TestMoveThreat(t *testing.T) {
+	tests := []struct {
+		name  string
+		board [][]int
+		x, y  int
+		want  ThreatKind
+	}{
+		{"lone stone", boardWith(), 7, 7, ThreatNone},
+		{"open three", boardWith(Move{Player1, 7, 6}, Move{Player1, 7, 7}), 7, 8, ThreatThree},
+		{"split three", boardWith(Move{Player1, 7, 5}, Move{Player1, 7, 7}), 7, 6, ThreatThree},
+		{"closed three", boardWith(Move{Player2, 7, 5}, Move{Player1, 7, 6}, Move{Player1, 7, 7}), 7, 8, ThreatNone},
+		{"open four", boardWith(Move{Player1, 7, 5}, Move{Player1, 7, 6}, Move{Player1, 7, 7}), 7, 8, ThreatWinning},
+		{"closed four", boardWith(Move{Player2, 7, 4}, Move{Player1, 7, 5}, Move{Player1, 7, 6}, Move{Player1, 7, 7}), 7, 8, ThreatFour},
+		{"split four", boardWith(Move{Player1, 7, 5}, Move{Player1, 7, 6}, Move{Player1, 7, 8}), 7, 9, ThreatFour},
+		{"four at the edge", boardWith(Move{Player1, 0, 0}, Move{Player1, 0, 1}, Move{Player1, 0, 2}), 0, 3, ThreatFour},
+		{"five", boardWith(Move{Player1, 7, 4}, Move{Player1, 7, 5}, Move{Player1, 7, 6}, Move{Player1, 7, 7}), 7, 8, ThreatFive},
+		{"diagonal five", boardWith(Move{Player1, 3, 3}, Move{Player1, 4, 4}, Move{Player1, 5, 5}, Move{Player1, 6, 6}), 7, 7, ThreatFive},
+		{"double three", boardWith(Move{Player1, 7, 5}, Move{Player1, 7, 6}, Move{Player1, 5, 7}, Move{Player1, 6, 7}), 7, 7, ThreatWinning},
+		{"four-three", boardWith(Move{Player2, 7, 3}, Move{Player1, 7, 4}, Move{Player1, 7, 5}, Move{Player1, 7, 6}, Move{Player1, 5, 7}, Move{Player1, 6, 7}), 7, 7, ThreatWinning},
+		{"opponent stones", boardWith(Move{Player2, 7, 5}, Move{Player2, 7, 6}, Move{Player2, 7, 7}), 7, 8, ThreatNone},
+	}
+	for _, tt := range tests {
+		if got := moveThreat(tt.board, tt.x, tt.y, Player1); got != tt.want {
+			t.Errorf("%s: moveThreat(%d, %d) = %v, want %v", tt.name, tt.x, tt.y, got, tt.want)
+		}
+	}
+}
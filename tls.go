@@ -0,0 +1,163 @@
+// tls.go
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// TLS 相关的命令行选项
+type TLSOptions struct {
+	CertFile   string // 本端证书 (服务器证书, 或客户端证书)
+	KeyFile    string // 本端私钥
+	CAFile     string // 服务器: 用于校验客户端证书的 CA; 客户端: 用于校验服务器证书的 CA
+	SelfSigned bool   // 服务器: 生成临时自签名证书
+	Pin        string // 客户端: 固定服务器证书的 SHA-256 指纹 (自签名证书时使用)
+	Insecure   bool   // 客户端: 不校验服务器证书 (仅用于测试)
+}
+
+// 是否启用了 TLS
+func (o TLSOptions) Enabled() bool {
+	return o.CertFile != "" || o.SelfSigned || o.CAFile != "" || o.Pin != "" || o.Insecure
+}
+
+// 证书的 SHA-256 指纹 (十六进制, 冒号分隔)
+func CertFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// 规范化用户输入的指纹 (忽略大小写和分隔符)
+func normalizeFingerprint(fp string) string {
+	fp = strings.ToLower(fp)
+	fp = strings.NewReplacer(":", "", " ", "", "-", "").Replace(fp)
+	return fp
+}
+
+// 生成内存中的自签名证书, 供临时对局使用
+func generateSelfSignedCert() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	host, _ := os.Hostname()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "tictactoe " + host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(7 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost", host},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// 读取 PEM 格式的 CA 证书
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// 服务器端 TLS 配置; 指定 CAFile 时要求客户端出示由该 CA 签发的证书
+func ServerTLSConfig(o TLSOptions) (*tls.Config, error) {
+	var cert tls.Certificate
+	var err error
+	switch {
+	case o.CertFile != "" && o.KeyFile != "":
+		cert, err = tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+	case o.SelfSigned:
+		cert, err = generateSelfSignedCert()
+	default:
+		return nil, errors.New("server TLS needs --tls-cert and --tls-key, or --tls-self-signed")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load server certificate: %w", err)
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if o.CAFile != "" {
+		pool, err := loadCertPool(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client CA: %w", err)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// 客户端 TLS 配置; Pin 非空时只接受指纹匹配的服务器证书 (不依赖 CA)
+func ClientTLSConfig(o TLSOptions, serverName string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if o.CertFile != "" && o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if o.CAFile != "" {
+		pool, err := loadCertPool(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("load server CA: %w", err)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if o.Pin != "" {
+		want := normalizeFingerprint(o.Pin)
+		// 证书固定: 跳过常规链校验, 改为比较叶子证书指纹
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("server sent no certificate")
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			if hex.EncodeToString(sum[:]) != want {
+				return fmt.Errorf("server certificate fingerprint %s does not match pinned value", CertFingerprint(rawCerts[0]))
+			}
+			return nil
+		}
+	} else if o.Insecure {
+		cfg.InsecureSkipVerify = true
+	}
+	return cfg, nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 测试用的证书: 签发后写成 PEM 文件, 返回证书和私钥的路径
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+func newTestCert(t *testing.T, name string, tmpl *x509.Certificate, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.Subject = pkix.Name{CommonName: name}
+	tmpl.NotBefore, tmpl.NotAfter = time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	c := &testCert{cert: cert, key: key, certFile: filepath.Join(dir, name+".pem"), keyFile: filepath.Join(dir, name+".key")}
+	if err := os.WriteFile(c.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// 在回环地址上握手; 服务器握手成功后写一个字节, 客户端读到它才算成功
+// (TLS 1.3 中服务器在客户端的握手返回之后才校验客户端证书)
+func tlsHandshake(t *testing.T, server, client *tls.Config) error {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tc := tls.Server(conn, server)
+		if tc.Handshake() == nil {
+			tc.Write([]byte{1})
+		}
+	}()
+	conn, err := tls.Dial("tcp", ln.Addr().String(), client)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = io.ReadFull(conn, make([]byte, 1))
+	return err
+}
+
+func TestTLSFingerprintPin(t *testing.T) {
+	server, err := ServerTLSConfig(TLSOptions{SelfSigned: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := generateSelfSignedCert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		pin  string
+		ok   bool
+	}{
+		{"matching pin", CertFingerprint(server.Certificates[0].Certificate[0]), true},
+		{"matching pin without separators", normalizeFingerprint(CertFingerprint(server.Certificates[0].Certificate[0])), true},
+		{"wrong pin", CertFingerprint(other.Certificate[0]), false},
+	} {
+		client, err := ClientTLSConfig(TLSOptions{Pin: tt.pin}, "localhost")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tlsHandshake(t, server, client)
+		if tt.ok && err != nil {
+			t.Errorf("%s: handshake failed: %v", tt.name, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("%s: handshake succeeded, want failure", tt.name)
+		}
+	}
+
+	// 不固定指纹也没有 CA 时按常规校验, 自签名证书不被接受
+	client, err := ClientTLSConfig(TLSOptions{}, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tlsHandshake(t, server, client); err == nil {
+		t.Error("unpinned handshake with a self-signed certificate succeeded")
+	}
+}
+
+func TestTLSClientCertificate(t *testing.T) {
+	ca := newTestCert(t, "ca", &x509.Certificate{
+		IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}, nil)
+	serverCert := newTestCert(t, "server", &x509.Certificate{
+		KeyUsage: x509.KeyUsageDigitalSignature, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames: []string{"localhost"},
+	}, ca)
+	clientCert := newTestCert(t, "client", &x509.Certificate{
+		KeyUsage: x509.KeyUsageDigitalSignature, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+	selfSigned := newTestCert(t, "stranger", &x509.Certificate{
+		KeyUsage: x509.KeyUsageDigitalSignature, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, nil)
+
+	server, err := ServerTLSConfig(TLSOptions{CertFile: serverCert.certFile, KeyFile: serverCert.keyFile, CAFile: ca.certFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("ClientAuth = %v, want RequireAndVerifyClientCert", server.ClientAuth)
+	}
+	for _, tt := range []struct {
+		name string
+		cert *testCert
+		ok   bool
+	}{
+		{"CA-signed client certificate", clientCert, true},
+		{"no client certificate", nil, false},
+		{"client certificate from another issuer", selfSigned, false},
+	} {
+		opts := TLSOptions{CAFile: ca.certFile}
+		if tt.cert != nil {
+			opts.CertFile, opts.KeyFile = tt.cert.certFile, tt.cert.keyFile
+		}
+		client, err := ClientTLSConfig(opts, "localhost")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tlsHandshake(t, server, client)
+		if tt.ok && err != nil {
+			t.Errorf("%s: handshake failed: %v", tt.name, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("%s: handshake succeeded, want failure", tt.name)
+		}
+	}
+}
@@ -0,0 +1,435 @@
+// tournament.go
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 引擎之间的自动对局: 循环赛或挑战赛 (第一个引擎对其他每一个), 每对引擎轮流执先,
+// 可以从开局库开始, 多局并行, 最后输出胜负表和 Elo 估计; 可选 SPRT 提前停止
+
+// 参赛的引擎: --engine name=spec
+type entrant struct {
+	Name string
+	Spec string
+}
+
+// 一对引擎的成绩, 从 a 的角度计算
+type pairing struct {
+	a, b    int
+	wins    int
+	draws   int
+	losses  int
+	stopped bool   // SPRT 已经得出结论
+	verdict string // SPRT 的结论
+}
+
+func (p *pairing) games() int { return p.wins + p.draws + p.losses }
+
+// 一局对局的安排
+type gameJob struct {
+	index   int
+	pair    *pairing
+	first   int    // 执先 (玩家1) 的引擎下标
+	opening []Move // 开局, 为空表示从空棋盘开始
+}
+
+type tournament struct {
+	entrants []entrant
+	timeout  time.Duration
+	sprt     *sprtTest // 为 nil 时不做 SPRT
+	saveDir  string    // 保存每局记录的目录, 为空时不保存
+
+	mu    sync.Mutex
+	pairs []*pairing
+	done  int
+	total int
+}
+
+// 执行一局; 引擎出错或走出非法的一手判负
+func playEngineGame(engines [3]Engine, opening []Move) (*Game, error) {
+	g := NewGame()
+	for _, m := range opening {
+		if err := g.Play(g.currentPlayer, m.X, m.Y); err != nil {
+			return nil, fmt.Errorf("opening move %d: %w", len(g.moves)+1, err)
+		}
+	}
+	for !g.gameOver {
+		player := g.currentPlayer
+		m, err := engines[player].NextMove(g.Moves(), player)
+		if err == nil {
+			err = g.Play(player, m.X, m.Y)
+		}
+		if err != nil {
+			slog.Warn("Engine forfeits", "engine", engines[player].Name(), "player", player, "err", err)
+			g.winner, g.gameOver = 3-player, true
+		}
+	}
+	return g, nil
+}
+
+// 工作 goroutine: 每局启动一对新的引擎, 避免上一局的状态影响结果.
+// 多局并行, 所以内置 AI 默认单线程搜索
+func (t *tournament) worker(jobs <-chan gameJob, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for job := range jobs {
+		t.mu.Lock()
+		skip := job.pair.stopped
+		t.mu.Unlock()
+		if skip {
+			continue
+		}
+		second := job.pair.a + job.pair.b - job.first
+		var engines [3]Engine // 下标为玩家编号
+		var err error
+		seats := [3]int{Player1: job.first, Player2: second}
+		for _, player := range []int{Player1, Player2} {
+			if engines[player], err = NewEngine(t.entrants[seats[player]].Spec, t.timeout, 1); err != nil {
+				slog.Error("Failed to start engine", "game", job.index, "engine", t.entrants[seats[player]].Name, "err", err)
+				break
+			}
+		}
+		var g *Game
+		if err == nil {
+			g, err = playEngineGame(engines, job.opening)
+		}
+		for _, e := range engines[1:] {
+			if e != nil {
+				e.Close()
+			}
+		}
+		if err != nil {
+			slog.Error("Game not played", "game", job.index, "err", err)
+			continue
+		}
+		t.record(job, g, second)
+	}
+}
+
+// 记录一局的结果, 打印进度并检查 SPRT
+func (t *tournament) record(job gameJob, g *Game, second int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := job.pair
+	aFirst := job.first == p.a
+	switch {
+	case g.winner == Draw:
+		p.draws++
+	case (g.winner == Player1) == aFirst:
+		p.wins++
+	default:
+		p.losses++
+	}
+	t.done++
+	fmt.Println(T("tournament.game", t.done, t.total, t.entrants[job.first].Name, t.entrants[second].Name,
+		resultTag(g.winner), len(g.moves)))
+
+	if t.saveDir != "" {
+		rec := GameRecord{Date: time.Now(), Winner: g.winner, Moves: g.Moves()}
+		rec.Players[Player1], rec.Players[Player2] = t.entrants[job.first].Name, t.entrants[second].Name
+		path := filepath.Join(t.saveDir, fmt.Sprintf("game-%04d.txt", job.index))
+		if err := writeRecordFile(path, rec, NotationAlgebraic); err != nil {
+			slog.Warn("Failed to save game record", "file", path, "err", err)
+		}
+	}
+
+	if t.sprt != nil && !p.stopped {
+		llr := t.sprt.llr(p.wins, p.draws, p.losses)
+		switch {
+		case llr >= t.sprt.upper():
+			p.stopped, p.verdict = true, T("tournament.sprt_h1", t.sprt.elo1)
+		case llr <= t.sprt.lower():
+			p.stopped, p.verdict = true, T("tournament.sprt_h0", t.sprt.elo0)
+		}
+	}
+}
+
+// --- Elo 和 SPRT ---
+
+// 由得分率估计 Elo 差, 以及 95% 置信区间的半宽; 全胜或全负时没有有限的估计
+func eloEstimate(wins, draws, losses int) (elo, margin float64, ok bool) {
+	n := float64(wins + draws + losses)
+	if n == 0 {
+		return 0, 0, false
+	}
+	score := (float64(wins) + float64(draws)/2) / n
+	if score <= 0 || score >= 1 {
+		return 0, 0, false
+	}
+	variance := (float64(wins)*math.Pow(1-score, 2) + float64(draws)*math.Pow(0.5-score, 2) +
+		float64(losses)*math.Pow(score, 2)) / n
+	stderr := math.Sqrt(variance / n)
+	lo, hi := math.Max(score-1.96*stderr, 1e-6), math.Min(score+1.96*stderr, 1-1e-6)
+	return scoreToElo(score), (scoreToElo(hi) - scoreToElo(lo)) / 2, true
+}
+
+func scoreToElo(score float64) float64 { return -400 * math.Log10(1/score-1) }
+func eloToScore(elo float64) float64   { return 1 / (1 + math.Pow(10, -elo/400)) }
+
+// 序贯概率比检验: H0 为 Elo 差 = elo0, H1 为 Elo 差 = elo1 (从第一个引擎的角度)
+type sprtTest struct {
+	elo0, elo1  float64
+	alpha, beta float64
+}
+
+func (s *sprtTest) lower() float64 { return math.Log(s.beta / (1 - s.alpha)) }
+func (s *sprtTest) upper() float64 { return math.Log((1 - s.beta) / s.alpha) }
+
+// 对数似然比 (三项分布的正态近似, 与常见的测试框架一致);
+// 胜负各加半局作为先验, 避免全胜或全负时方差为 0
+func (s *sprtTest) llr(wins, draws, losses int) float64 {
+	w, d, l := float64(wins)+0.5, float64(draws), float64(losses)+0.5
+	n := w + d + l
+	score := (w + d/2) / n
+	variance := (w*math.Pow(1-score, 2) + d*math.Pow(0.5-score, 2) + l*math.Pow(score, 2)) / n
+	s0, s1 := eloToScore(s.elo0), eloToScore(s.elo1)
+	return (s1 - s0) * (2*score - s0 - s1) * n / (2 * variance)
+}
+
+// "elo0,elo1"
+func parseSPRT(spec string, alpha, beta float64) (*sprtTest, error) {
+	var s sprtTest
+	if _, err := fmt.Sscanf(spec, "%g,%g", &s.elo0, &s.elo1); err != nil || s.elo0 >= s.elo1 {
+		return nil, fmt.Errorf("invalid --sprt %q: want elo0,elo1 with elo0 < elo1", spec)
+	}
+	if alpha <= 0 || alpha >= 1 || beta <= 0 || beta >= 1 {
+		return nil, errors.New("--alpha and --beta must be between 0 and 1")
+	}
+	s.alpha, s.beta = alpha, beta
+	return &s, nil
+}
+
+// --- 开局 ---
+
+// 开局文件: 每行一个落子序列 ("h8 i9 h10"), # 开头的行是注释
+func loadOpenings(r io.Reader) ([][]Move, error) {
+	var openings [][]Move
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		moves, _, err := ParseMoveText(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		openings = append(openings, moves)
+	}
+	return openings, scanner.Err()
+}
+
+// --- 报告 ---
+
+func (t *tournament) report(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, T("tournament.pairings"))
+	for _, p := range t.pairs {
+		if p.games() == 0 {
+			continue
+		}
+		line := T("tournament.pair_line", t.entrants[p.a].Name, t.entrants[p.b].Name, p.wins, p.draws, p.losses,
+			100*(float64(p.wins)+float64(p.draws)/2)/float64(p.games()), formatElo(p.wins, p.draws, p.losses))
+		if t.sprt != nil {
+			line += "  " + T("tournament.llr", t.sprt.llr(p.wins, p.draws, p.losses), t.sprt.lower(), t.sprt.upper())
+			if p.verdict != "" {
+				line += "  " + p.verdict
+			}
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	// 每个引擎对其他所有引擎的总成绩
+	type standing struct{ w, d, l int }
+	totals := make([]standing, len(t.entrants))
+	for _, p := range t.pairs {
+		totals[p.a].w += p.wins
+		totals[p.a].d += p.draws
+		totals[p.a].l += p.losses
+		totals[p.b].w += p.losses
+		totals[p.b].d += p.draws
+		totals[p.b].l += p.wins
+	}
+	nameWidth := 6
+	for _, e := range t.entrants {
+		nameWidth = max(nameWidth, stringWidth(e.Name))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%-*s %6s %5s %5s %5s %7s  %s\n", nameWidth, T("tournament.col_engine"), T("tournament.col_games"),
+		"W", "D", "L", T("tournament.col_score"), T("tournament.col_elo"))
+	for i, e := range t.entrants {
+		s := totals[i]
+		n := s.w + s.d + s.l
+		if n == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%-*s %6d %5d %5d %5d %6.1f%%  %s\n", nameWidth, e.Name, n, s.w, s.d, s.l,
+			100*(float64(s.w)+float64(s.d)/2)/float64(n), formatElo(s.w, s.d, s.l))
+	}
+}
+
+// "+35 ± 20", 无法估计时为 "-"
+func formatElo(wins, draws, losses int) string {
+	elo, margin, ok := eloEstimate(wins, draws, losses)
+	if !ok {
+		return "-"
+	}
+	if math.Abs(elo) < 0.5 {
+		elo = 0 // 避免显示 "-0"
+	}
+	return fmt.Sprintf("%+.0f ± %.0f", elo, margin)
+}
+
+// --- tournament 子命令 ---
+
+// 可重复的 --engine 参数
+type entrantList []entrant
+
+func (l *entrantList) String() string { return fmt.Sprint(*l) }
+
+func (l *entrantList) Set(v string) error {
+	name, spec, ok := strings.Cut(v, "=")
+	if !ok {
+		name, spec = v, v
+	}
+	name, spec = strings.TrimSpace(name), strings.TrimSpace(spec)
+	if name == "" || spec == "" {
+		return fmt.Errorf("invalid engine %q: want name=spec", v)
+	}
+	for _, e := range *l {
+		if e.Name == name {
+			return fmt.Errorf("duplicate engine name %q", name)
+		}
+	}
+	*l = append(*l, entrant{Name: name, Spec: spec})
+	return nil
+}
+
+// tictactoe tournament --engine a=builtin --engine b="pbrain-x" [选项]
+func runTournament(args []string) {
+	fs := flag.NewFlagSet("tournament", flag.ExitOnError)
+	var entrants entrantList
+	fs.Var(&entrants, "engine", "Engine as name=spec (repeat): spec is builtin[:strength=ELO,depth=N,width=N,time=D,threads=N,book=FILE,...] or a Piskvork engine command line")
+	format := fs.String("format", "roundrobin", "roundrobin (every pair) or gauntlet (the first engine against each other one)")
+	games := fs.Int("games", 2, "Games per pairing (rounded up to an even number so colours alternate)")
+	openingsFile := fs.String("openings", "", "File with one opening move sequence per line; each opening is played with both colours")
+	bookFile := fs.String("book", "", "Opening book to draw a random opening from for each pair of games (instead of --openings)")
+	bookPlies := fs.Int("book-plies", 4, "Number of moves to take from the --book")
+	concurrency := fs.Int("concurrency", runtime.GOMAXPROCS(0), "Games played in parallel")
+	timeout := fs.Duration("time", time.Second, "Time limit per move")
+	sprtSpec := fs.String("sprt", "", "Stop a pairing early by SPRT: elo0,elo1 (e.g. 0,10), from the first engine's side")
+	alpha := fs.Float64("alpha", 0.05, "SPRT false positive rate")
+	beta := fs.Float64("beta", 0.05, "SPRT false negative rate")
+	saveDir := fs.String("save", "", "Directory to save every game record in")
+	lang := fs.String("lang", "", "Interface language, e.g. en or zh-CN (default from $LC_ALL, $LC_MESSAGES or $LANG)")
+	fs.Parse(args)
+	SetLanguage(*lang)
+
+	if len(entrants) < 2 {
+		log.Fatal("tournament: at least two --engine entries are required")
+	}
+	t := &tournament{entrants: entrants, timeout: *timeout, saveDir: *saveDir}
+	if *sprtSpec != "" {
+		var err error
+		if t.sprt, err = parseSPRT(*sprtSpec, *alpha, *beta); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *saveDir != "" {
+		if err := os.MkdirAll(*saveDir, 0o755); err != nil {
+			log.Fatal(err)
+		}
+	}
+	openings := [][]Move{nil}
+	if *openingsFile != "" {
+		err := withInputFile(*openingsFile, func(r io.Reader) (err error) {
+			openings, err = loadOpenings(r)
+			return err
+		})
+		if err != nil {
+			log.Fatalf("Failed to load openings: %v", err)
+		}
+		if len(openings) == 0 {
+			log.Fatal("tournament: the openings file is empty")
+		}
+	}
+	var book *OpeningBook
+	if *bookFile != "" {
+		if *openingsFile != "" {
+			log.Fatal("tournament: use either --openings or --book, not both")
+		}
+		var err error
+		if book, err = LoadOpeningBookFile(*bookFile); err != nil {
+			log.Fatal(err)
+		}
+		if book.Len() == 0 {
+			log.Fatal("tournament: the opening book is empty")
+		}
+	}
+
+	switch *format {
+	case "roundrobin":
+		for a := range entrants {
+			for b := a + 1; b < len(entrants); b++ {
+				t.pairs = append(t.pairs, &pairing{a: a, b: b})
+			}
+		}
+	case "gauntlet":
+		for b := 1; b < len(entrants); b++ {
+			t.pairs = append(t.pairs, &pairing{a: 0, b: b})
+		}
+	default:
+		log.Fatalf("unknown format %q (available: roundrobin, gauntlet)", *format)
+	}
+
+	// 每个开局双方各执先一次; 各对引擎交错排列, 提前停止时其他对不受影响.
+	// 使用开局库时每两局随机取一个开局, 各对引擎用同样的开局
+	perPair := (max(*games, 1) + 1) / 2 * 2
+	var jobs []gameJob
+	var opening []Move
+	for i := 0; i < perPair; i++ {
+		switch {
+		case i%2 == 1:
+		case book != nil:
+			opening = book.RandomLine(*bookPlies)
+		default:
+			opening = openings[(i/2)%len(openings)]
+		}
+		for _, p := range t.pairs {
+			first := p.a
+			if i%2 == 1 {
+				first = p.b
+			}
+			jobs = append(jobs, gameJob{index: len(jobs) + 1, pair: p, first: first, opening: opening})
+		}
+	}
+	t.total = len(jobs)
+
+	queue := make(chan gameJob)
+	var wg sync.WaitGroup
+	for i := 0; i < max(*concurrency, 1); i++ {
+		wg.Add(1)
+		go t.worker(queue, &wg)
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+	t.report(os.Stdout)
+}
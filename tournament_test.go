@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEloEstimate(t *testing.T) {
+	tests := []struct {
+		wins, draws, losses int
+		elo                 float64
+		ok                  bool
+	}{
+		{50, 0, 50, 0, true},
+		{0, 100, 0, 0, true}, // 全和: 方差为 0, 区间宽度也是 0
+		{75, 0, 25, 190.85, true},
+		{25, 0, 75, -190.85, true},
+		{50, 20, 30, 70.44, true}, // 得分率 60%
+		{10, 0, 0, 0, false},      // 全胜
+		{0, 3, 7, -301.33, true},  // 一局没赢, 但有和棋: 得分率 15%
+		{0, 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		elo, margin, ok := eloEstimate(tt.wins, tt.draws, tt.losses)
+		if ok != tt.ok {
+			t.Errorf("eloEstimate(%d, %d, %d) ok = %v, want %v", tt.wins, tt.draws, tt.losses, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if math.Abs(elo-tt.elo) > 0.01 {
+			t.Errorf("eloEstimate(%d, %d, %d) = %.2f, want %.2f", tt.wins, tt.draws, tt.losses, elo, tt.elo)
+		}
+		if margin < 0 || math.IsInf(margin, 0) {
+			t.Errorf("eloEstimate(%d, %d, %d) margin = %v, want finite and non-negative", tt.wins, tt.draws, tt.losses, margin)
+		}
+	}
+
+	// 局数越多, 置信区间越窄
+	_, m100, _ := eloEstimate(60, 0, 40)
+	_, m1000, _ := eloEstimate(600, 0, 400)
+	if m1000 >= m100 {
+		t.Errorf("margin with 1000 games (%.1f) not below margin with 100 games (%.1f)", m1000, m100)
+	}
+}
+
+func TestSPRT(t *testing.T) {
+	s, err := parseSPRT("0,10", 0.05, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(s.lower()+2.944) > 0.001 || math.Abs(s.upper()-2.944) > 0.001 {
+		t.Errorf("bounds = [%.3f, %.3f], want [-2.944, 2.944]", s.lower(), s.upper())
+	}
+
+	tests := []struct {
+		wins, draws, losses int
+		llr                 float64
+	}{
+		{600, 0, 400, 5.5625},
+		{300, 400, 300, -0.6902},
+	}
+	for _, tt := range tests {
+		if got := s.llr(tt.wins, tt.draws, tt.losses); math.Abs(got-tt.llr) > 0.0001 {
+			t.Errorf("llr(%d, %d, %d) = %.4f, want %.4f", tt.wins, tt.draws, tt.losses, got, tt.llr)
+		}
+	}
+	if got := s.llr(10, 0, 0); math.IsInf(got, 0) || math.IsNaN(got) {
+		t.Errorf("llr with all wins = %v, want finite", got)
+	}
+
+	// 得分率正好在 elo0 和 elo1 中间时没有倾向
+	mid, _ := parseSPRT("-5,5", 0.05, 0.05)
+	if got := mid.llr(1000, 2000, 1000); math.Abs(got) > 1e-9 {
+		t.Errorf("llr at the midpoint = %v, want 0", got)
+	}
+
+	for _, bad := range []struct {
+		spec        string
+		alpha, beta float64
+	}{
+		{"10,0", 0.05, 0.05},
+		{"5,5", 0.05, 0.05},
+		{"abc", 0.05, 0.05},
+		{"0,10", 0, 0.05},
+		{"0,10", 0.05, 1},
+	} {
+		if _, err := parseSPRT(bad.spec, bad.alpha, bad.beta); err == nil {
+			t.Errorf("parseSPRT(%q, %v, %v) succeeded, want error", bad.spec, bad.alpha, bad.beta)
+		}
+	}
+}
+
+func TestLoadOpenings(t *testing.T) {
+	openings, err := loadOpenings(strings.NewReader("# 开局\nh8 i9 h10\n\n7,7 6,8\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(openings) != 2 || len(openings[0]) != 3 || len(openings[1]) != 2 {
+		t.Errorf("loadOpenings = %v, want 2 openings of 3 and 2 moves", openings)
+	}
+	if _, err := loadOpenings(strings.NewReader("h8 h8\n")); err == nil {
+		t.Error("loadOpenings with an illegal opening succeeded, want error")
+	}
+}
@@ -0,0 +1,82 @@
+// transport.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+// 传输层: 在一条连接上收发 Message (TCP/TLS 上的 JSON 流, 或 WebSocket)
+type Transport interface {
+	Send(msg Message) error
+	Receive(msg *Message) error
+	SetReadDeadline(t time.Time) error
+	RemoteAddr() net.Addr
+	Close() error
+}
+
+// TCP (或 TLS) 连接上的换行分隔 JSON 流
+type jsonTransport struct {
+	conn    net.Conn
+	encoder *json.Encoder
+	decoder *json.Decoder
+	sendMu  sync.Mutex // 避免多个 goroutine 并发写同一个 encoder
+}
+
+func NewJSONTransport(conn net.Conn) Transport {
+	return &jsonTransport{
+		conn:    conn,
+		encoder: json.NewEncoder(conn),
+		decoder: json.NewDecoder(conn),
+	}
+}
+
+func (t *jsonTransport) Send(msg Message) error {
+	t.sendMu.Lock()
+	defer t.sendMu.Unlock()
+	return t.encoder.Encode(msg)
+}
+
+func (t *jsonTransport) Receive(msg *Message) error {
+	return t.decoder.Decode(msg)
+}
+
+// 收到的数据不是合法的消息 (而不是连接出错)
+func isDecodeError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+func (t *jsonTransport) SetReadDeadline(deadline time.Time) error {
+	return t.conn.SetReadDeadline(deadline)
+}
+
+func (t *jsonTransport) RemoteAddr() net.Addr { return t.conn.RemoteAddr() }
+func (t *jsonTransport) Close() error         { return t.conn.Close() }
+
+// Goroutine: 接受 TCP 连接并交给 incoming (由主流程逐个登录)
+func acceptTCP(listener net.Listener, incoming chan<- Transport) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			slog.Info("Stopped accepting TCP connections", "err", err)
+			return
+		}
+		countConnection("tcp")
+		incoming <- NewJSONTransport(conn)
+	}
+}
+
+// Goroutine: 对局开始后拒绝多余的连接
+func rejectExtraConnections(incoming <-chan Transport) {
+	for t := range incoming {
+		slog.Info("Rejecting connection: game already in progress", "remote", t.RemoteAddr())
+		t.Send(Message{Type: MsgTypeError, Content: "Game already in progress"})
+		t.Close()
+	}
+}
@@ -0,0 +1,82 @@
+// tt.go
+package main
+
+import "sync/atomic"
+
+// 置换表, 供 AI 的多线程搜索共享; 键是 Bitboard 的 Zobrist 哈希
+
+// 表项记录的分值是准确值, 下界还是上界
+const (
+	boundExact = iota
+	boundLower // 发生了 beta 截断, 实际分值不低于记录的分值
+	boundUpper // 没有着法超过 alpha, 实际分值不高于记录的分值
+)
+
+const ttDefaultBits = 20 // 默认 2^20 项, 每项 16 字节
+
+// 置换表, 可以被多个 goroutine 同时读写而不加锁: 每项存 key^data 和 data 两个字,
+// 读到的两个字不属于同一次写入时校验不通过, 当作没有命中
+type transTable struct {
+	entries []ttSlot
+	mask    uint64
+}
+
+type ttSlot struct {
+	check atomic.Uint64 // hash ^ data
+	data  atomic.Uint64
+}
+
+// 解码后的表项
+type ttEntry struct {
+	score int
+	depth int
+	bound int
+	move  int // x*BoardSize+y, -1 表示没有
+}
+
+func newTransTable(bits int) *transTable {
+	return &transTable{entries: make([]ttSlot, 1<<bits), mask: 1<<bits - 1}
+}
+
+// data 的布局: 低 32 位分值, 之后 8 位深度, 2 位边界类型, 8 位着法 (+1, 0 表示没有)
+func (t *transTable) store(hash uint64, e ttEntry) {
+	data := uint64(uint32(int32(e.score))) | uint64(e.depth&0xff)<<32 | uint64(e.bound)<<40 | uint64(e.move+1)<<42
+	slot := &t.entries[hash&t.mask]
+	slot.check.Store(hash ^ data)
+	slot.data.Store(data)
+}
+
+func (t *transTable) probe(hash uint64) (ttEntry, bool) {
+	slot := &t.entries[hash&t.mask]
+	data := slot.data.Load()
+	if slot.check.Load()^data != hash {
+		return ttEntry{}, false
+	}
+	return ttEntry{
+		score: int(int32(uint32(data))),
+		depth: int(data >> 32 & 0xff),
+		bound: int(data >> 40 & 3),
+		move:  int(data>>42&0xff) - 1,
+	}, true
+}
+
+// 胜负分值和所在的层数有关, 存入时换算成相对当前节点的值, 取出时换回来
+func scoreToTT(score, ply int) int {
+	switch {
+	case score >= scoreWin-scoreWinMargin:
+		return score + ply
+	case score <= -scoreWin+scoreWinMargin:
+		return score - ply
+	}
+	return score
+}
+
+func scoreFromTT(score, ply int) int {
+	switch {
+	case score >= scoreWin-scoreWinMargin:
+		return score - ply
+	case score <= -scoreWin+scoreWinMargin:
+		return score + ply
+	}
+	return score
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTransTableStoreProbe(t *testing.T) {
+	tt := newTransTable(8)
+	entries := []ttEntry{
+		{score: 0, depth: 0, bound: boundExact, move: -1},
+		{score: 123, depth: 4, bound: boundLower, move: 0},
+		{score: -4567, depth: 12, bound: boundUpper, move: BoardSize*BoardSize - 1},
+		{score: scoreWin - 3, depth: 255, bound: boundExact, move: 7*BoardSize + 7},
+		{score: -scoreWin + 9, depth: 1, bound: boundLower, move: 42},
+	}
+	for i, e := range entries {
+		hash := uint64(i+1)*0x9e3779b97f4a7c15 | 1
+		tt.store(hash, e)
+		got, ok := tt.probe(hash)
+		if !ok || got != e {
+			t.Errorf("probe after store(%+v) = %+v, %v", e, got, ok)
+		}
+	}
+}
+
+func TestTransTableMiss(t *testing.T) {
+	tt := newTransTable(4)
+	if _, ok := tt.probe(0x1234); ok {
+		t.Error("probe on an empty table hit")
+	}
+
+	// 同一个槽位的另一个局面不算命中, 写入后覆盖旧表项
+	a, b := uint64(0xabc0), uint64(0xdef0) // 低 4 位相同
+	tt.store(a, ttEntry{score: 1, depth: 2, move: -1})
+	if _, ok := tt.probe(b); ok {
+		t.Error("probe of a different hash in the same slot hit")
+	}
+	tt.store(b, ttEntry{score: 5, depth: 6, move: -1})
+	if _, ok := tt.probe(a); ok {
+		t.Error("probe of an overwritten entry hit")
+	}
+	if e, ok := tt.probe(b); !ok || e.score != 5 {
+		t.Errorf("probe(b) = %+v, %v; want score 5", e, ok)
+	}
+
+	// 两个字来自不同的写入 (并发写入时可能读到) 时校验不通过
+	slot := &tt.entries[a&tt.mask]
+	tt.store(a, ttEntry{score: 1, depth: 2, move: -1})
+	check := slot.check.Load()
+	tt.store(a, ttEntry{score: 9, depth: 3, move: -1})
+	slot.check.Store(check)
+	if e, ok := tt.probe(a); ok {
+		t.Errorf("probe of a torn entry hit: %+v", e)
+	}
+}
+
+// 多个 goroutine 同时读写同一批槽位: 命中的表项必须是某一次完整的写入
+func TestTransTableConcurrent(t *testing.T) {
+	tt := newTransTable(2)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 20000; i++ {
+				hash := 1<<32 | uint64(i%16)<<8 | uint64(w+1) // 空槽位的两个字都是 0, 所以哈希避开 0
+				n := i % 200
+				tt.store(hash, ttEntry{score: n * 10, depth: n % 100, bound: boundLower, move: n})
+				if e, ok := tt.probe(hash ^ 1); ok && (e.score != e.move*10 || e.depth != e.move%100) {
+					t.Errorf("probe returned a mixed entry %+v", e)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+func TestScoreTT(t *testing.T) {
+	tests := []struct {
+		score, ply, stored int
+	}{
+		{0, 5, 0},
+		{500, 3, 500},
+		{-800, 7, -800},
+		{scoreWin - 6, 4, scoreWin - 2}, // 根节点看是 6 步后赢, 相对于第 4 层只差 2 步
+		{-scoreWin + 6, 4, -scoreWin + 2},
+	}
+	for _, tt := range tests {
+		stored := scoreToTT(tt.score, tt.ply)
+		if stored != tt.stored {
+			t.Errorf("scoreToTT(%d, %d) = %d, want %d", tt.score, tt.ply, stored, tt.stored)
+		}
+		if got := scoreFromTT(stored, tt.ply); got != tt.score {
+			t.Errorf("scoreFromTT(%d, %d) = %d, want %d", stored, tt.ply, got, tt.score)
+		}
+	}
+	// 同一个胜局在更深的层取出时离根节点更远
+	if got := scoreFromTT(scoreToTT(scoreWin-6, 4), 8); got != scoreWin-10 {
+		t.Errorf("win stored at ply 4 and probed at ply 8 = %d, want %d", got, scoreWin-10)
+	}
+}
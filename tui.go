@@ -0,0 +1,598 @@
+// tui.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ANSI 控制序列
+const (
+	ansiAltScreenOn  = "\033[?1049h"
+	ansiAltScreenOff = "\033[?1049l"
+	ansiClearLine    = "\033[K"
+	ansiClearBelow   = "\033[J"
+	ansiHome         = "\033[H"
+	ansiReverse      = "\033[7m"
+	ansiBold         = "\033[1m"
+	ansiReset        = "\033[0m"
+	ansiUnderline    = "\033[4m"
+	ansiMouseOn      = "\033[?1003h\033[?1006h" // 报告所有鼠标事件 (含移动), SGR 编码
+	ansiMouseOff     = "\033[?1003l\033[?1006l"
+)
+
+const (
+	tuiMinChatW  = 24 // 聊天窗格放在棋盘右侧所需的最小宽度
+	tuiChatPage  = 10 // PgUp/PgDn 每次回滚的条数
+	tuiBoardLine = 2  // 渲染器输出的第一行在屏幕上的行号 (从 1 开始, 标题行之后)
+)
+
+// 按键 (由 readLoop 解析原始输入得到)
+type tuiKey int
+
+const (
+	keyNone tuiKey = iota
+	keyRune
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyEnter
+	keyTab
+	keyEsc
+	keyBackspace
+	keyCtrlC
+	keyMouse
+	keyPgUp
+	keyPgDn
+)
+
+// 一个输入事件: 按键, 或 SGR 编码的鼠标事件
+type tuiEvent struct {
+	key    tuiKey
+	r      rune // keyRune 时的字符
+	button int  // 鼠标按钮编码 (0: 左键; 加 32 表示移动)
+	col    int  // 鼠标所在屏幕列 (从 1 开始)
+	row    int  // 鼠标所在屏幕行 (从 1 开始)
+	press  bool // 按下 (M) 还是松开 (m)
+}
+
+// 全屏终端界面: 光标选点落子, 独立的聊天输入行和状态栏
+type TUI struct {
+	mu        sync.Mutex
+	fd        int
+	oldState  *term.State
+	cursorX   int    // 光标所在行
+	cursorY   int    // 光标所在列
+	chatMode  bool   // 输入焦点在聊天行
+	chatInput []rune // 正在输入的聊天内容
+	chatSkip  int    // 聊天窗格向上回滚的条数, 0 表示显示最新的消息
+	closed    bool
+
+	mouse        bool // 是否开启了鼠标报告
+	confirmClick bool // 点击一次只选中, 再次点击同一点才落子
+	hoverX       int  // 鼠标悬停的行, -1 表示不在棋盘上
+	hoverY       int
+	pendingX     int // 等待确认的点, -1 表示没有
+	pendingY     int
+
+	renderer  Renderer // 棋盘的绘制方式
+	boardTop  int      // 棋盘第 0 行在屏幕上的行号 (从 1 开始)
+	boardLeft int      // 棋盘第 0 列的格子在屏幕上的起始列 (从 1 开始)
+	gridOK    bool     // 渲染器是网格布局, 可以用鼠标定位
+
+	logPath    string    // 界面运行期间日志写入的文件
+	logFile    *os.File  // 打开的日志文件, Close 时关闭; 为 nil 时日志仍写到标准错误
+	restoreLog func()    // 恢复界面启动前的 slog 默认记录器
+	gameStart  time.Time // 对局开始时间 (分配玩家编号后)
+	turnStart  time.Time // 当前回合开始时间
+	lastTurn   int       // 上次绘制时轮到的玩家, 用于检测回合切换
+	lastRender time.Time
+}
+
+// 进入原始模式和备用屏幕; 日志改写到临时文件, 避免破坏界面
+// mouse 为 true 时开启 xterm 鼠标报告
+func NewTUI(renderer Renderer, mouse, confirmClick bool) (*TUI, error) {
+	fd := int(os.Stdin.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	t := &TUI{
+		fd:       fd,
+		oldState: oldState,
+		cursorX:  BoardSize / 2,
+		cursorY:  BoardSize / 2,
+		renderer: renderer,
+
+		mouse:        mouse,
+		confirmClick: confirmClick,
+		hoverX:       -1,
+		hoverY:       -1,
+		pendingX:     -1,
+		pendingY:     -1,
+	}
+	line, col, ok := renderer.CellOrigin()
+	t.boardTop, t.boardLeft, t.gridOK = tuiBoardLine+line, col+1, ok
+	if f, err := openTUILog(); err == nil {
+		t.logFile, t.logPath = f, f.Name()
+		log.SetOutput(f)
+		t.restoreLog = redirectStderrLog(f)
+	}
+	fmt.Print(ansiAltScreenOn)
+	if mouse {
+		fmt.Print(ansiMouseOn)
+	}
+	return t, nil
+}
+
+// 恢复终端 (可重复调用)
+func (t *TUI) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.closed = true
+	if t.mouse {
+		fmt.Print(ansiMouseOff)
+	}
+	fmt.Print(ansiAltScreenOff)
+	term.Restore(t.fd, t.oldState)
+	log.SetOutput(os.Stderr)
+	if t.logFile != nil {
+		t.restoreLog()
+		t.logFile.Close()
+		fmt.Println(T("tui.log_written", t.logPath))
+	}
+}
+
+// 打开界面运行期间的日志文件: 放在用户自己的缓存目录 (只有本人可写), 不用共享临时目录中
+// 可以预测的文件名, 以免被别人用符号链接指向其他文件. 没有缓存目录时在临时目录中新建唯一的文件
+func openTUILog() (*os.File, error) {
+	if dir, err := os.UserCacheDir(); err == nil {
+		dir = filepath.Join(dir, "tictactoe")
+		if err := os.MkdirAll(dir, 0o700); err == nil {
+			f, err := os.OpenFile(filepath.Join(dir, "tictactoe.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+			if err == nil {
+				return f, nil
+			}
+		}
+	}
+	return os.CreateTemp("", "tictactoe-*.log")
+}
+
+// 聊天窗格向上回滚 n 条 (绘制时限制在第一页)
+func (t *TUI) ScrollChat(n int) {
+	t.mu.Lock()
+	t.chatSkip += n
+	t.mu.Unlock()
+}
+
+// 距离上次绘制超过一秒, 需要刷新计时
+func (t *TUI) NeedsClockRedraw() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !t.closed && time.Since(t.lastRender) >= time.Second
+}
+
+// --- 输入 ---
+
+// 解析 SGR 鼠标序列 "\033[<b;col;rowM" (或以 m 结尾表示松开), 返回消耗的字节数
+func parseMouse(buf []byte) (tuiEvent, int, bool) {
+	if !bytes.HasPrefix(buf, []byte("\033[<")) {
+		return tuiEvent{}, 0, false
+	}
+	end := 3
+	for end < len(buf) && (buf[end] >= '0' && buf[end] <= '9' || buf[end] == ';') {
+		end++
+	}
+	if end == len(buf) { // 序列不完整
+		return tuiEvent{}, 0, false
+	}
+	if buf[end] != 'M' && buf[end] != 'm' {
+		return tuiEvent{}, end, true // 不是鼠标序列的字节, 丢弃之前的部分, 从这里继续解析
+	}
+	var ev tuiEvent
+	if _, err := fmt.Sscanf(string(buf[3:end]), "%d;%d;%d", &ev.button, &ev.col, &ev.row); err != nil {
+		return tuiEvent{}, end + 1, true // 格式不对, 丢弃整段
+	}
+	ev.key = keyMouse
+	ev.press = buf[end] == 'M'
+	return ev, end + 1, true
+}
+
+// 把一段原始输入解析为事件序列
+func parseKeys(buf []byte) []tuiEvent {
+	var events []tuiEvent
+	add := func(key tuiKey, n int) {
+		events = append(events, tuiEvent{key: key})
+		buf = buf[n:]
+	}
+	for len(buf) > 0 {
+		if ev, n, ok := parseMouse(buf); ok {
+			if ev.key == keyMouse {
+				events = append(events, ev)
+			}
+			buf = buf[n:]
+			continue
+		}
+		switch {
+		case bytes.HasPrefix(buf, []byte("\033[A")), bytes.HasPrefix(buf, []byte("\033OA")):
+			add(keyUp, 3)
+		case bytes.HasPrefix(buf, []byte("\033[B")), bytes.HasPrefix(buf, []byte("\033OB")):
+			add(keyDown, 3)
+		case bytes.HasPrefix(buf, []byte("\033[C")), bytes.HasPrefix(buf, []byte("\033OC")):
+			add(keyRight, 3)
+		case bytes.HasPrefix(buf, []byte("\033[D")), bytes.HasPrefix(buf, []byte("\033OD")):
+			add(keyLeft, 3)
+		case bytes.HasPrefix(buf, []byte("\033[5~")):
+			add(keyPgUp, 4)
+		case bytes.HasPrefix(buf, []byte("\033[6~")):
+			add(keyPgDn, 4)
+		case buf[0] == '\033' && len(buf) > 1 && buf[1] == '[':
+			// 其他不认识的 CSI 序列: 跳过到结束字节
+			i := 2
+			for i < len(buf) && (buf[i] < 0x40 || buf[i] > 0x7e) {
+				i++
+			}
+			buf = buf[min(i+1, len(buf)):]
+		case buf[0] == '\033':
+			add(keyEsc, 1)
+		case buf[0] == '\r' || buf[0] == '\n':
+			add(keyEnter, 1)
+		case buf[0] == '\t':
+			add(keyTab, 1)
+		case buf[0] == 0x7f || buf[0] == 0x08:
+			add(keyBackspace, 1)
+		case buf[0] == 0x03:
+			add(keyCtrlC, 1)
+		default:
+			r, size := utf8.DecodeRune(buf)
+			buf = buf[size:]
+			if r != utf8.RuneError && unicode.IsPrint(r) {
+				events = append(events, tuiEvent{key: keyRune, r: r})
+			}
+		}
+	}
+	return events
+}
+
+// 屏幕坐标对应的棋盘交叉点; 不在棋盘上时 ok 为 false
+func (t *TUI) screenToBoard(col, row int) (x, y int, ok bool) {
+	x = row - t.boardTop
+	if !t.gridOK || col < t.boardLeft || x < 0 || x >= BoardSize {
+		return 0, 0, false
+	}
+	y = (col - t.boardLeft) / 3
+	if y >= BoardSize {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// Goroutine: 以原始模式读取按键, 取代 inputReader
+// 移动光标只在本地重绘; 落子和聊天转换成与逐行界面相同的命令送入 inputChan
+func (t *TUI) readLoop(gs *GameState) {
+	defer func() {
+		slog.Debug("TUI input reader exiting")
+		select {
+		case <-gs.quitChan:
+		default:
+			close(gs.quitChan)
+		}
+	}()
+	buf := make([]byte, 256)
+	for {
+		n, err := stdinReader.Read(buf)
+		if err != nil {
+			slog.Error("Error reading input", "err", err)
+			return
+		}
+		for _, ev := range parseKeys(buf[:n]) {
+			command, quit := t.handleEvent(ev)
+			if quit {
+				return
+			}
+			if command != "" {
+				select {
+				case gs.inputChan <- command:
+				case <-gs.quitChan:
+					return
+				}
+			}
+			gs.SetNeedsRedraw()
+		}
+	}
+}
+
+// 处理一个输入事件, 返回要交给 handleUserInput 的命令; quit 为 true 表示退出
+func (t *TUI) handleEvent(ev tuiEvent) (command string, quit bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key, r := ev.key, ev.r
+	if key == keyCtrlC {
+		return "", true
+	}
+	if key == keyMouse {
+		return t.handleMouseInternal(ev), false
+	}
+	switch key { // 聊天回滚在两种输入焦点下都可用
+	case keyPgUp:
+		t.chatSkip += tuiChatPage
+		return "", false
+	case keyPgDn:
+		t.chatSkip = max(t.chatSkip-tuiChatPage, 0)
+		return "", false
+	}
+	t.pendingX, t.pendingY = -1, -1 // 键盘操作取消待确认的点击
+
+	if t.chatMode {
+		switch key {
+		case keyRune:
+			t.chatInput = append(t.chatInput, r)
+		case keyBackspace:
+			if len(t.chatInput) > 0 {
+				t.chatInput = t.chatInput[:len(t.chatInput)-1]
+			}
+		case keyEnter:
+			text := strings.TrimSpace(string(t.chatInput))
+			t.chatInput = t.chatInput[:0]
+			t.chatMode = false
+			if strings.HasPrefix(text, "/") {
+				return text, false // 斜杠命令 (/me, /help ...) 原样交给 handleUserInput
+			}
+			if text != "" {
+				return "/c " + text, false
+			}
+		case keyEsc, keyTab:
+			t.chatMode = false
+		}
+		return "", false
+	}
+
+	switch key {
+	case keyUp:
+		t.cursorX = max(t.cursorX-1, 0)
+	case keyDown:
+		t.cursorX = min(t.cursorX+1, BoardSize-1)
+	case keyLeft:
+		t.cursorY = max(t.cursorY-1, 0)
+	case keyRight:
+		t.cursorY = min(t.cursorY+1, BoardSize-1)
+	case keyEnter:
+		return fmt.Sprintf("%d,%d", t.cursorX, t.cursorY), false
+	case keyTab:
+		t.chatMode = true
+	case keyRune:
+		switch r {
+		case 'k':
+			t.cursorX = max(t.cursorX-1, 0)
+		case 'j':
+			t.cursorX = min(t.cursorX+1, BoardSize-1)
+		case 'h':
+			t.cursorY = max(t.cursorY-1, 0)
+		case 'l':
+			t.cursorY = min(t.cursorY+1, BoardSize-1)
+		case ' ':
+			return fmt.Sprintf("%d,%d", t.cursorX, t.cursorY), false
+		case 'c', 't', '/':
+			t.chatMode = true
+		case 'q':
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// 处理鼠标事件 (需要在外部加锁调用)
+func (t *TUI) handleMouseInternal(ev tuiEvent) string {
+	switch ev.button { // 滚轮: 回滚聊天记录
+	case 64:
+		t.chatSkip += 3
+		return ""
+	case 65:
+		t.chatSkip = max(t.chatSkip-3, 0)
+		return ""
+	}
+	x, y, onBoard := t.screenToBoard(ev.col, ev.row)
+	if ev.button&32 != 0 { // 移动: 只更新悬停位置
+		if onBoard {
+			t.hoverX, t.hoverY = x, y
+		} else {
+			t.hoverX, t.hoverY = -1, -1
+		}
+		return ""
+	}
+	if ev.button != 0 || !ev.press || !onBoard { // 只处理左键按下
+		return ""
+	}
+	t.chatMode = false
+	t.cursorX, t.cursorY = x, y
+	if t.confirmClick && (t.pendingX != x || t.pendingY != y) {
+		t.pendingX, t.pendingY = x, y // 第一次点击: 选中, 等待确认
+		return ""
+	}
+	t.pendingX, t.pendingY = -1, -1
+	return fmt.Sprintf("%d,%d", x, y)
+}
+
+// --- 绘制 ---
+
+// 截断到指定的显示宽度 (宽字符占 2 列)
+func truncateWidth(s string, width int) string {
+	w := 0
+	for i, r := range s {
+		if w += runeWidth(r); w > width {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// 绘制整个界面 (在主循环中调用)
+func (t *TUI) Render(gs *GameState) {
+	// 先取快照, 避免持锁绘制
+	gs.mu.Lock()
+	view := gs.boardViewInternal()
+	moveCount := len(gs.moves)
+	myID, turn, gameOver, winner := gs.playerID, gs.currentPlayer, gs.gameOver, gs.winner
+	myName, peerName := gs.userName, gs.peerName
+	gs.mu.Unlock()
+	gs.chatMu.Lock()
+	chat := make([]string, len(gs.chatHistory))
+	for i, e := range gs.chatHistory {
+		chat[i] = e.String()
+	}
+	gs.chatMu.Unlock()
+	notice := gs.Notice()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	now := time.Now()
+	if myID != 0 && t.gameStart.IsZero() {
+		t.gameStart = now
+	}
+	if turn != t.lastTurn {
+		t.lastTurn = turn
+		t.turnStart = now
+	}
+	t.lastRender = now
+
+	width, height, err := term.GetSize(t.fd)
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 80, 24
+	}
+
+	// 棋盘区域 (左侧)
+	if !t.chatMode {
+		view.CursorX, view.CursorY = t.cursorX, t.cursorY
+	}
+	view.HoverX, view.HoverY = t.hoverX, t.hoverY
+	view.PendingX, view.PendingY = t.pendingX, t.pendingY
+	left := t.renderer.RenderBoard(view)
+	boardWidth := 0
+	for _, row := range left {
+		boardWidth = max(boardWidth, visibleWidth(row))
+	}
+
+	// 聊天窗格: 宽度足够时放在右侧, 否则放在棋盘下方
+	chatBeside := width >= boardWidth+2+tuiMinChatW
+	chatWidth := width
+	chatRows := height - len(left) - 3 // 标题行, 输入行, 状态栏
+	if chatBeside {
+		chatWidth = width - boardWidth - 2
+		chatRows = len(left) - 1
+	}
+	// 回滚时只显示 chatSkip 条之前的消息, 最多回滚到第一页
+	visible := max(chatRows-1, 0)
+	t.chatSkip = min(t.chatSkip, max(len(chat)-visible, 0))
+	end := len(chat) - t.chatSkip
+	chat = chat[max(end-visible, 0):end]
+	chatLines := []string{T("chat.header")}
+	if t.chatSkip > 0 {
+		chatLines[0] = T("tui.chat_scrolled", t.chatSkip)
+	}
+	for _, line := range chat {
+		chatLines = append(chatLines, truncateWidth(line, chatWidth))
+	}
+
+	var screen strings.Builder
+	screen.WriteString(ansiHome)
+	writeLine := func(s string) {
+		screen.WriteString(s + ansiClearLine + "\r\n")
+	}
+
+	title := T("tui.title", myName)
+	if peerName != "" {
+		title = T("tui.title_vs", myName, peerName)
+	}
+	writeLine(ansiBold + truncateWidth(title, width) + ansiReset)
+	lines := 1
+	for i, row := range left {
+		if chatBeside && i < len(chatLines) {
+			row += strings.Repeat(" ", boardWidth-visibleWidth(row)+2) + chatLines[i]
+		}
+		writeLine(row)
+		lines++
+	}
+	if !chatBeside {
+		for _, line := range chatLines {
+			if lines >= height-2 {
+				break
+			}
+			writeLine(line)
+			lines++
+		}
+	}
+	for lines < height-2 {
+		writeLine("")
+		lines++
+	}
+
+	// 输入行
+	inputLine := T("tui.help")
+	if t.mouse {
+		inputLine = T("tui.help_mouse")
+	}
+	if t.pendingX >= 0 {
+		inputLine = T("tui.confirm_click", view.Notation.Format(t.pendingX, t.pendingY))
+	}
+	if t.chatMode {
+		inputLine = T("tui.chat_prompt") + string(t.chatInput)
+	}
+	writeLine(truncateWidth(inputLine, width))
+
+	// 状态栏
+	var status string
+	switch {
+	case gameOver:
+		status = T("tui.game_over", resultText(t.renderer, winner, myID))
+	case myID == 0:
+		status = T("tui.waiting_assignment")
+	case turn == myID:
+		status = T("tui.your_turn", t.renderer.StoneName(myID), formatClock(now.Sub(t.turnStart)))
+	default:
+		status = T("tui.waiting_for", turn, t.renderer.StoneName(turn), formatClock(now.Sub(t.turnStart)))
+	}
+	if !t.gameStart.IsZero() {
+		status += " | " + T("tui.game_clock", formatClock(now.Sub(t.gameStart)))
+	}
+	if view.LastMove != nil {
+		status += " | " + Tn("tui.moves", moveCount, view.Notation.Format(view.LastMove.X, view.LastMove.Y))
+	}
+	if notice != "" {
+		status += " | " + notice
+	}
+	status = truncateWidth(status, width)
+	screen.WriteString(ansiReverse + status + strings.Repeat(" ", max(width-stringWidth(status), 0)) + ansiReset)
+	screen.WriteString(ansiClearBelow)
+
+	// 把终端光标放在棋盘光标或聊天输入处
+	if t.chatMode {
+		col := min(stringWidth(T("tui.chat_prompt"))+stringWidth(string(t.chatInput))+1, width)
+		fmt.Fprintf(&screen, "\033[%d;%dH", height-1, col)
+	} else if t.gridOK {
+		fmt.Fprintf(&screen, "\033[%d;%dH", t.boardTop+t.cursorX, t.boardLeft+t.cursorY*3+1)
+	}
+	os.Stdout.WriteString(screen.String())
+}
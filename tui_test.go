@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMouse(t *testing.T) {
+	tests := []struct {
+		in   string
+		ev   tuiEvent
+		n    int
+		ok   bool
+		name string
+	}{
+		{"\033[<0;10;5M", tuiEvent{key: keyMouse, button: 0, col: 10, row: 5, press: true}, 10, true, "left press"},
+		{"\033[<0;10;5m", tuiEvent{key: keyMouse, button: 0, col: 10, row: 5}, 10, true, "left release"},
+		{"\033[<35;120;40Mx", tuiEvent{key: keyMouse, button: 35, col: 120, row: 40, press: true}, 13, true, "motion, followed by a key"},
+		{"\033[<0;10", tuiEvent{}, 0, false, "partial sequence"},
+		{"\033[<", tuiEvent{}, 0, false, "only the prefix"},
+		{"\033[<0;10;5xM", tuiEvent{}, 9, true, "stray byte before the final M"},
+		{"\033[<0;;M", tuiEvent{}, 7, true, "missing numbers"},
+		{"\033[A", tuiEvent{}, 0, false, "arrow key"},
+		{"x", tuiEvent{}, 0, false, "plain key"},
+	}
+	for _, tt := range tests {
+		ev, n, ok := parseMouse([]byte(tt.in))
+		if ev != tt.ev || n != tt.n || ok != tt.ok {
+			t.Errorf("%s: parseMouse(%q) = %+v, %d, %v; want %+v, %d, %v", tt.name, tt.in, ev, n, ok, tt.ev, tt.n, tt.ok)
+		}
+	}
+
+	// 与按键混在一起时逐个解析, 不把后面按键的字节当作鼠标序列的结尾
+	got := parseKeys([]byte("\033[<0;10;5Ma\033[<0;10;5mb"))
+	want := []tuiEvent{
+		{key: keyMouse, col: 10, row: 5, press: true},
+		{key: keyRune, r: 'a'},
+		{key: keyMouse, col: 10, row: 5},
+		{key: keyRune, r: 'b'},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeys = %+v, want %+v", got, want)
+	}
+	if got := parseKeys([]byte("\033[<0;1xM")); !reflect.DeepEqual(got, []tuiEvent{{key: keyRune, r: 'x'}, {key: keyRune, r: 'M'}}) {
+		t.Errorf("parseKeys of a broken sequence = %+v, want the keys after it", got)
+	}
+}
+
+// 网格棋盘每格占 3 列; ascii 主题的棋盘从屏幕第 4 行, 第 4 列开始
+func TestScreenToBoard(t *testing.T) {
+	rd, err := NewRenderer("ascii")
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, col, ok := rd.CellOrigin()
+	tui := &TUI{boardTop: tuiBoardLine + line, boardLeft: col + 1, gridOK: ok}
+	if tui.boardTop != 4 || tui.boardLeft != 4 {
+		t.Fatalf("board origin at row %d, column %d; want 4, 4", tui.boardTop, tui.boardLeft)
+	}
+	last := BoardSize - 1
+	tests := []struct {
+		col, row int
+		x, y     int
+		ok       bool
+	}{
+		{4, 4, 0, 0, true},
+		{6, 4, 0, 0, true}, // 同一格的第三列
+		{7, 4, 0, 1, true},
+		{4 + 3*last + 2, 4 + last, last, last, true}, // 右下角
+		{3, 4, 0, 0, false},                          // 左边框
+		{4 + 3*BoardSize, 4, 0, 0, false},            // 右边之外
+		{4, 3, 0, 0, false},                          // 列号行
+		{4, 4 + BoardSize, 0, 0, false},              // 棋盘下方
+	}
+	for _, tt := range tests {
+		x, y, ok := tui.screenToBoard(tt.col, tt.row)
+		if x != tt.x || y != tt.y || ok != tt.ok {
+			t.Errorf("screenToBoard(%d, %d) = %d, %d, %v; want %d, %d, %v", tt.col, tt.row, x, y, ok, tt.x, tt.y, tt.ok)
+		}
+	}
+
+	// 非网格的渲染器不能用鼠标定位
+	tui.gridOK = false
+	if _, _, ok := tui.screenToBoard(4, 4); ok {
+		t.Error("screenToBoard succeeded without a grid layout")
+	}
+}
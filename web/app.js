@@ -0,0 +1,188 @@
+// Browser client for the gomoku server. Speaks the same JSON Message
+// protocol as the terminal client, one message per WebSocket frame.
+"use strict";
+
+const BOARD_SIZE = 15;
+const EMPTY = 0;
+const DRAW = 3;
+const INVALID_MOVE = "Received invalid move"; // the server's error for a rejected move
+
+const state = {
+  ws: null,
+  playerID: 0,
+  peerName: "",
+  turn: 0,
+  winner: 0,
+  gameOver: false,
+  board: [],
+  pending: null, // {x, y} of our move until the server's state or error answers it
+};
+
+const $ = (id) => document.getElementById(id);
+
+function resetBoard() {
+  state.board = [];
+  for (let i = 0; i < BOARD_SIZE; i++) {
+    state.board.push(new Array(BOARD_SIZE).fill(EMPTY));
+  }
+}
+
+function buildBoard() {
+  const el = $("board");
+  el.innerHTML = "";
+  for (let x = 0; x < BOARD_SIZE; x++) {
+    for (let y = 0; y < BOARD_SIZE; y++) {
+      const cell = document.createElement("button");
+      cell.type = "button";
+      cell.dataset.x = x;
+      cell.dataset.y = y;
+      cell.setAttribute("aria-label", `row ${x}, column ${y}`);
+      cell.addEventListener("click", () => placeStone(x, y));
+      el.appendChild(cell);
+    }
+  }
+}
+
+function render() {
+  const myTurn = !state.gameOver && state.playerID !== 0 && state.turn === state.playerID && !state.pending;
+  const p = state.pending;
+  for (const cell of $("board").children) {
+    const x = Number(cell.dataset.x);
+    const y = Number(cell.dataset.y);
+    const v = state.board[x][y];
+    if (p && p.x === x && p.y === y) {
+      cell.className = `p${state.playerID} pending`;
+    } else {
+      cell.className = v === EMPTY ? "" : `p${v}`;
+    }
+    cell.disabled = !myTurn || v !== EMPTY;
+  }
+
+  let status;
+  if (state.gameOver) {
+    if (state.winner === DRAW) status = "Game over: it's a draw!";
+    else if (state.winner === state.playerID) status = "Game over: you win!";
+    else if (state.winner !== 0) status = `Game over: Player ${state.winner} wins.`;
+    else status = "Game ended.";
+  } else if (state.playerID === 0) {
+    status = "Waiting for player assignment...";
+  } else if (myTurn) {
+    status = `Your turn (Player ${state.playerID}).`;
+  } else {
+    status = `Waiting for ${state.peerName || "Player " + state.turn}'s move...`;
+  }
+  $("status").textContent = status;
+}
+
+// "/me waves" is sent as-is and shown as an emote, the same as the terminal client
+function chatLine(sender, text) {
+  const time = new Date().toTimeString().slice(0, 5);
+  if (text.startsWith("/me ")) {
+    return `${time} * ${sender} ${text.slice(4)}`;
+  }
+  return `${time} [${sender}]: ${text}`;
+}
+
+function addChat(line) {
+  const li = document.createElement("li");
+  li.textContent = line; // textContent: never interpret chat as HTML
+  $("chat-log").appendChild(li);
+  li.scrollIntoView({ block: "nearest" });
+}
+
+function send(msg) {
+  if (state.ws && state.ws.readyState === WebSocket.OPEN) {
+    state.ws.send(JSON.stringify(msg));
+  }
+}
+
+function placeStone(x, y) {
+  if (state.gameOver || state.turn !== state.playerID || state.pending || state.board[x][y] !== EMPTY) {
+    return;
+  }
+  // Shown as pending until the server answers: a state message accepts the move,
+  // an invalid-move error rejects it and the stone disappears again
+  state.pending = { x, y };
+  send({ type: "move", player: state.playerID, x, y });
+  render();
+}
+
+function handleMessage(msg) {
+  // omitempty on the Go side drops zero values
+  const x = msg.x || 0;
+  const y = msg.y || 0;
+  switch (msg.type) {
+    case "assign":
+      state.playerID = msg.player;
+      state.peerName = msg.user || "";
+      state.turn = 1; // Player 1 always moves first
+      addChat(`You are Player ${state.playerID}, playing against ${state.peerName || "Player " + (3 - state.playerID)}.`);
+      break;
+    case "move":
+      if (state.board[x][y] === EMPTY) {
+        state.board[x][y] = 3 - state.playerID;
+        state.turn = state.playerID;
+      }
+      break;
+    case "state":
+      if (state.pending) {
+        state.board[state.pending.x][state.pending.y] = state.playerID;
+        state.pending = null;
+      }
+      state.turn = msg.turn || 0;
+      state.winner = msg.winner || 0;
+      state.gameOver = state.winner !== 0;
+      break;
+    case "chat":
+      addChat(chatLine(state.peerName || "Player " + (3 - state.playerID), msg.content || ""));
+      break;
+    case "error":
+      if (msg.content === INVALID_MOVE) {
+        state.pending = null;
+      }
+      addChat(`Error: ${msg.content || ""}`);
+      break;
+    case "notify":
+      addChat(msg.content || "");
+      break;
+  }
+  render();
+}
+
+function connect(user, password, token) {
+  const scheme = location.protocol === "https:" ? "wss" : "ws";
+  const ws = new WebSocket(`${scheme}://${location.host}/ws`);
+  state.ws = ws;
+  ws.addEventListener("open", () => {
+    send({ type: "login", user, password, token });
+  });
+  ws.addEventListener("message", (ev) => handleMessage(JSON.parse(ev.data)));
+  ws.addEventListener("close", () => {
+    if (!state.gameOver) {
+      state.gameOver = true;
+      addChat("Connection closed.");
+    }
+    render();
+  });
+}
+
+$("login-form").addEventListener("submit", (ev) => {
+  ev.preventDefault();
+  $("login").hidden = true;
+  $("game").hidden = false;
+  resetBoard();
+  buildBoard();
+  render();
+  connect($("user").value.trim(), $("password").value, $("token").value.trim());
+});
+
+$("chat-form").addEventListener("submit", (ev) => {
+  ev.preventDefault();
+  const text = $("chat-input").value.trim();
+  if (text === "" || state.playerID === 0) {
+    return;
+  }
+  send({ type: "chat", player: state.playerID, content: text });
+  addChat(chatLine("You", text));
+  $("chat-input").value = "";
+});
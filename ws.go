@@ -0,0 +1,91 @@
+// ws.go
+package main
+
+import (
+	"crypto/tls"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// 一帧消息的最大长度 (字节); 最长的聊天消息编码后也远小于此
+const wsMaxMessage = 8 << 10
+
+// 浏览器客户端 (静态 HTML/JS), 编译进二进制
+//
+//go:embed web
+var webFiles embed.FS
+
+// WebSocket 上的 JSON 文本帧, 每帧一条 Message
+type wsTransport struct {
+	conn   *websocket.Conn
+	sendMu sync.Mutex // gorilla/websocket 不允许并发写
+}
+
+func (t *wsTransport) Send(msg Message) error {
+	t.sendMu.Lock()
+	defer t.sendMu.Unlock()
+	return t.conn.WriteJSON(msg)
+}
+
+func (t *wsTransport) Receive(msg *Message) error {
+	err := t.conn.ReadJSON(msg)
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+		return io.EOF // 与 TCP 连接关闭的处理保持一致
+	}
+	return err
+}
+
+func (t *wsTransport) SetReadDeadline(deadline time.Time) error {
+	return t.conn.SetReadDeadline(deadline)
+}
+
+func (t *wsTransport) RemoteAddr() net.Addr { return t.conn.RemoteAddr() }
+func (t *wsTransport) Close() error         { return t.conn.Close() }
+
+// 在 addr 上提供浏览器客户端 (/) 和 WebSocket 接入点 (/ws)
+// tlsConfig 非空时使用 HTTPS/WSS; 服务停止时返回原因
+func serveWebSocket(addr string, tlsConfig *tls.Config, incoming chan<- Transport) error {
+	handler, err := webHandler(incoming)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Addr: addr, Handler: handler, TLSConfig: tlsConfig}
+	if tlsConfig != nil {
+		err = server.ListenAndServeTLS("", "") // 证书来自 tlsConfig
+	} else {
+		err = server.ListenAndServe()
+	}
+	return fmt.Errorf("web server stopped: %w", err)
+}
+
+// 浏览器客户端的静态文件和 /ws 接入点; 升级成功的连接送到 incoming
+func webHandler(incoming chan<- Transport) (http.Handler, error) {
+	static, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		return nil, fmt.Errorf("load web client: %w", err)
+	}
+	upgrader := websocket.Upgrader{} // 默认只允许同源页面连接
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(static)))
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			slog.Warn("WebSocket upgrade failed", "remote", r.RemoteAddr, "err", err)
+			return
+		}
+		conn.SetReadLimit(wsMaxMessage) // 超长的帧使 Receive 出错, 不会整帧读进内存
+		countConnection("ws")
+		incoming <- &wsTransport{conn: conn}
+	})
+	return mux, nil
+}
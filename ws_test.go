@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// 浏览器客户端的 JSON 帧经过 /ws 接入点来回传递; 超长的帧被拒绝
+func TestWebSocketRoundTrip(t *testing.T) {
+	incoming := make(chan Transport, 1)
+	handler, err := webHandler(incoming)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	dial := func() (*websocket.Conn, Transport) {
+		t.Helper()
+		client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client, <-incoming
+	}
+
+	client, server := dial()
+	defer server.Close()
+	if err := client.WriteMessage(websocket.TextMessage, []byte(`{"type":"move","player":1,"x":7,"y":8}`)); err != nil {
+		t.Fatal(err)
+	}
+	var got Message
+	if err := server.Receive(&got); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Message{Type: MsgTypeMove, Player: Player1, X: 7, Y: 8}); got != want {
+		t.Errorf("server received %+v, want %+v", got, want)
+	}
+	sent := Message{Type: MsgTypeState, Turn: Player2, Hash: "00c0ffee12345678"}
+	if err := server.Send(sent); err != nil {
+		t.Fatal(err)
+	}
+	var echoed Message
+	if err := client.ReadJSON(&echoed); err != nil || echoed != sent {
+		t.Errorf("client received %+v, %v; want %+v", echoed, err, sent)
+	}
+	// 浏览器正常关闭连接时 Receive 返回 io.EOF, 与 TCP 相同
+	client.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	if err := server.Receive(&got); err != io.EOF {
+		t.Errorf("Receive after close = %v, want io.EOF", err)
+	}
+	client.Close()
+
+	client, server = dial()
+	defer client.Close()
+	defer server.Close()
+	huge := `{"type":"chat","content":"` + strings.Repeat("x", wsMaxMessage) + `"}`
+	go client.WriteMessage(websocket.TextMessage, []byte(huge))
+	if err := server.Receive(&got); !errors.Is(err, websocket.ErrReadLimit) {
+		t.Errorf("Receive of a %d-byte frame = %v, want ErrReadLimit", len(huge), err)
+	}
+}